@@ -0,0 +1,148 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestBus subscribes to runs on both engines with filters and each buffering policy, and checks that a
+// subscriber only gets the events it takes, that a run nobody takes CellFlipped from still gives the right
+// board, that a DropOldest subscriber that is never read keeps the latest events without holding the run up,
+// and that the flips a Coalesce subscriber gets after the run still add up to the final board.
+func TestBus(t *testing.T) {
+	for _, engine := range []string{"channels", "shared"} {
+		t.Run(engine+"/filter", func(t *testing.T) {
+			p := gol.Params{Turns: 100, Threads: 4, ImageWidth: 64, ImageHeight: 64, Engine: engine}
+			bus := gol.NewBus()
+			all := bus.Subscribe(1000, gol.Block)
+			final := bus.Subscribe(1, gol.Block, gol.FinalTurnComplete{})
+			p.OutDir = testOut
+			go gol.RunWithBus(p, bus, nil, nil)
+			for range all {
+			}
+			var finals []gol.Event
+			for event := range final {
+				finals = append(finals, event)
+			}
+			if len(finals) != 1 {
+				t.Fatalf("Expected only the FinalTurnComplete event, got %v", finals)
+			}
+			e, ok := finals[0].(gol.FinalTurnComplete)
+			if !ok {
+				t.Fatalf("Expected FinalTurnComplete, got %T", finals[0])
+			}
+			assertEqualBoard(t, e.Alive, readAliveCells("check/images/64x64x100.pgm", 64, 64), p)
+		})
+
+		t.Run(engine+"/quiet", func(t *testing.T) {
+			p := gol.Params{Turns: 100, Threads: 8, ImageWidth: 512, ImageHeight: 512, Engine: engine}
+			bus := gol.NewBus()
+			events := bus.Subscribe(1, gol.Block, gol.FinalTurnComplete{})
+			p.OutDir = testOut
+			go gol.RunWithBus(p, bus, nil, nil)
+			var alive []util.Cell
+			for event := range events {
+				switch e := event.(type) {
+				case gol.FinalTurnComplete:
+					alive = e.Alive
+				default:
+					t.Errorf("Unexpected event %T", event)
+				}
+			}
+			assertEqualBoard(t, alive, readAliveCells("check/images/512x512x100.pgm", 512, 512), p)
+		})
+
+		t.Run(engine+"/drop-oldest", func(t *testing.T) {
+			p := gol.Params{Turns: 100, Threads: 4, ImageWidth: 64, ImageHeight: 64, Engine: engine}
+			bus := gol.NewBus()
+			latest := bus.Subscribe(10, gol.DropOldest)
+			done := bus.Subscribe(1, gol.Block, gol.FinalTurnComplete{})
+			p.OutDir = testOut
+			go gol.RunWithBus(p, bus, nil, nil)
+			for range done {
+			}
+			var events []gol.Event
+			for event := range latest {
+				events = append(events, event)
+			}
+			if len(events) == 0 || len(events) > 10 {
+				t.Fatalf("Expected up to 10 events, got %d", len(events))
+			}
+			if e, ok := events[len(events)-1].(gol.StateChange); !ok || e.NewState != gol.Quitting {
+				t.Errorf("Expected the last event to be Quitting, got %v", events[len(events)-1])
+			}
+		})
+
+		t.Run(engine+"/coalesce", func(t *testing.T) {
+			p := gol.Params{Turns: 100, Threads: 4, ImageWidth: 64, ImageHeight: 64, Engine: engine}
+			bus := gol.NewBus()
+			//room for every cell and the last TurnComplete, so that the run never waits for the events to be read
+			merged := bus.Subscribe(64*64+1, gol.Coalesce, gol.CellFlipped{}, gol.TurnComplete{})
+			done := bus.Subscribe(1, gol.Block, gol.FinalTurnComplete{})
+			p.OutDir = testOut
+			go gol.RunWithBus(p, bus, nil, nil)
+			var alive []util.Cell
+			for event := range done {
+				alive = event.(gol.FinalTurnComplete).Alive
+			}
+			board := make(map[util.Cell]bool)
+			turn := -1
+			for event := range merged {
+				switch e := event.(type) {
+				case gol.CellFlipped:
+					board[e.Cell] = !board[e.Cell]
+				case gol.TurnComplete:
+					turn = e.CompletedTurns
+				}
+			}
+			if turn != p.Turns {
+				t.Errorf("Expected the last TurnComplete to be of turn %d, got %d", p.Turns, turn)
+			}
+			var flipped []util.Cell
+			for cell, on := range board {
+				if on {
+					flipped = append(flipped, cell)
+				}
+			}
+			assertEqualBoard(t, flipped, alive, p)
+		})
+	}
+
+	t.Run("unbuffered", func(t *testing.T) {
+		bus := gol.NewBus()
+		latest := bus.Subscribe(0, gol.DropOldest)
+		published := make(chan bool)
+		go func() {
+			for turn := 1; turn <= 3; turn++ {
+				bus.Publish(gol.TurnComplete{CompletedTurns: turn})
+			}
+			bus.Close()
+			published <- true
+		}()
+		select {
+		case <-published:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Publishing to a DropOldest subscriber of size 0 that is not read did not return within 5s")
+		}
+		var events []gol.Event
+		for event := range latest {
+			events = append(events, event)
+		}
+		expected := []gol.Event{gol.TurnComplete{CompletedTurns: 3}}
+		if !reflect.DeepEqual(events, expected) {
+			t.Errorf("Expected only the latest event %v, got %v", expected, events)
+		}
+	})
+
+	t.Run("late", func(t *testing.T) {
+		bus := gol.NewBus()
+		bus.Close()
+		if _, ok := <-bus.Subscribe(1, gol.Block); ok {
+			t.Error("Expected a subscription to a closed bus to be closed")
+		}
+	})
+}
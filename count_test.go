@@ -12,69 +12,77 @@ import (
 	"uk.ac.bris.cs/gameoflife/util"
 )
 
-// TestAlive will automatically check the 512x512 cell counts for the first 5 messages.
+// TestAlive will automatically check the 512x512 cell counts for the first 5 messages, on both engines.
 // You can manually check your counts by looking at CSVs provided in check/alive
 func TestAlive(t *testing.T) {
-	p := gol.Params{
-		Turns:       100000000,
-		Threads:     8,
-		ImageWidth:  512,
-		ImageHeight: 512,
-	}
-	alive := readAliveCounts(p.ImageWidth, p.ImageHeight)
-	events := make(chan gol.Event)
-	keyPresses := make(chan rune, 2)
-	go gol.Run(p, events, keyPresses)
+	for _, engine := range []string{"channels", "shared"} {
+		t.Run(engine, func(t *testing.T) {
+			p := gol.Params{
+				Turns:       100000000,
+				Threads:     8,
+				ImageWidth:  512,
+				ImageHeight: 512,
+				Engine:      engine,
+			}
+			alive := readAliveCounts(p.ImageWidth, p.ImageHeight)
+			events := make(chan gol.Event)
+			keyPresses := make(chan rune, 2)
+			p.OutDir = testOut
+			go gol.Run(p, events, keyPresses)
 
-	implemented := make(chan bool)
-	go func() {
-		timer := time.After(5 * time.Second)
-		select {
-		case <-timer:
-			t.Fatal("no AliveCellsCount events received in 5 seconds")
-		case <-implemented:
-			return
-		}
-	}()
+			implemented := make(chan bool)
+			go func() {
+				timer := time.After(5 * time.Second)
+				select {
+				case <-timer:
+					t.Fatal("no AliveCellsCount events received in 5 seconds")
+				case <-implemented:
+					return
+				}
+			}()
 
-	i := 0
-	turn := 0
-	for event := range events {
-		switch e := event.(type) {
-		case gol.TurnComplete:
-			turn++
-		case gol.AliveCellsCount:
-			var expected int
-			if e.CompletedTurns != turn {
-				t.Fatalf("Expected turn to be %v, got %v instead", turn, e.CompletedTurns)
-			}
-			if e.CompletedTurns == 0 {
-				t.Fatal("Count reported for turn 0, should have a delay.")
-			}
-			if e.CompletedTurns <= 10000 {
-				expected = alive[e.CompletedTurns]
-			} else if e.CompletedTurns%2 == 0 {
-				expected = 5565
-			} else {
-				expected = 5567
-			}
-			actual := e.CellsCount
-			if expected != actual {
-				t.Fatalf("At turn %v expected %v alive cells, got %v instead", e.CompletedTurns, expected, actual)
-			} else {
-				fmt.Println(event)
-				if i == 0 {
-					implemented <- true
+			i := 0
+			turn := 0
+			for event := range events {
+				switch e := event.(type) {
+				case gol.TurnComplete:
+					turn++
+				case gol.AliveCellsCount:
+					var expected int
+					if e.CompletedTurns != turn {
+						t.Fatalf("Expected turn to be %v, got %v instead", turn, e.CompletedTurns)
+					}
+					if e.CompletedTurns == 0 {
+						t.Fatal("Count reported for turn 0, should have a delay.")
+					}
+					if e.CompletedTurns <= 10000 {
+						expected = alive[e.CompletedTurns]
+					} else if e.CompletedTurns%2 == 0 {
+						expected = 5565
+					} else {
+						expected = 5567
+					}
+					actual := e.CellsCount
+					if expected != actual {
+						t.Fatalf("At turn %v expected %v alive cells, got %v instead", e.CompletedTurns, expected, actual)
+					} else {
+						fmt.Println(event)
+						if i == 0 {
+							implemented <- true
+						}
+						i++
+					}
+				}
+				if i >= 5 {
+					keyPresses <- 'q'
+					for range events {
+					}
+					return
 				}
-				i++
 			}
-		}
-		if i >= 5 {
-			keyPresses <- 'q'
-			return
-		}
+			t.Fatal("not enough AliveCellsCount events received")
+		})
 	}
-	t.Fatal("not enough AliveCellsCount events received")
 }
 
 func readAliveCounts(width, height int) map[int]int {
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestEdit pauses a 16x16 run, clears the board, paints a blinker and randomises a corner of the board,
+// then checks that the CellFlipped events and the final state include the edits.
+func TestEdit(t *testing.T) {
+	p := gol.Params{Turns: 100000000, Threads: 4, ImageWidth: 16, ImageHeight: 16}
+	events := make(chan gol.Event)
+	keyPresses := make(chan rune, 10)
+	edits := make(chan gol.Edit)
+	p.OutDir = testOut
+	go gol.RunWithEdits(p, events, keyPresses, edits)
+
+	board := make(map[util.Cell]bool)
+	timeout := time.After(10 * time.Second)
+	// next returns the next event that is not a CellFlipped, keeping track of the board on the way.
+	next := func() gol.Event {
+		for {
+			select {
+			case event := <-events:
+				if e, ok := event.(gol.CellFlipped); ok {
+					board[e.Cell] = !board[e.Cell]
+					continue
+				}
+				return event
+			case <-timeout:
+				t.Fatal("No events for 10s")
+			}
+		}
+	}
+	// edit sends an edit while draining events, and waits for it to be applied.
+	edit := func(e gol.Edit) gol.WorldEdited {
+		go func() { edits <- e }()
+		for {
+			if edited, ok := next().(gol.WorldEdited); ok {
+				return edited
+			}
+		}
+	}
+
+	keyPresses <- 'p'
+	for {
+		if e, ok := next().(gol.StateChange); ok && e.NewState == gol.Paused {
+			break
+		}
+	}
+	edit(gol.Edit{Kind: gol.EditClear, Cell: util.Cell{X: 15, Y: 15}, Corner: util.Cell{X: 0, Y: 0}})
+	for y := 7; y <= 9; y++ {
+		if edited := edit(gol.Edit{Kind: gol.EditSet, Cell: util.Cell{X: 8, Y: y}, Alive: true}); edited.Flipped != 1 {
+			t.Errorf("Expected painting an empty cell to flip 1 cell, flipped %d", edited.Flipped)
+		}
+	}
+	if edited := edit(gol.Edit{Kind: gol.EditSet, Cell: util.Cell{X: 8, Y: 9}, Alive: true}); edited.Flipped != 0 {
+		t.Errorf("Expected painting an alive cell to flip nothing, flipped %d", edited.Flipped)
+	}
+	// a still life block in the corner, far enough from the blinker not to touch it
+	edit(gol.Edit{Kind: gol.EditPaste, Cell: util.Cell{X: 1, Y: 1}, Pattern: [][]uint8{{0xFF, 0xFF}, {0xFF, 0xFF}}})
+	// randomising the same rectangle with the same seed twice gives the same cells
+	randomise := gol.Edit{Kind: gol.EditRandomise, Cell: util.Cell{X: 12, Y: 0}, Corner: util.Cell{X: 15, Y: 3}, Seed: 7}
+	edit(randomise)
+	if edited := edit(randomise); edited.Flipped != 0 {
+		t.Errorf("Expected randomising with the same seed again to flip nothing, flipped %d", edited.Flipped)
+	}
+	edit(gol.Edit{Kind: gol.EditClear, Cell: util.Cell{X: 12, Y: 0}, Corner: util.Cell{X: 15, Y: 3}})
+
+	keyPresses <- 'p'
+	//let the edited world run for a few turns before quitting
+	for {
+		if e, ok := next().(gol.TurnComplete); ok && e.CompletedTurns >= 3 {
+			break
+		}
+	}
+	keyPresses <- 'q'
+	var final gol.FinalTurnComplete
+	for {
+		if e, ok := next().(gol.FinalTurnComplete); ok {
+			final = e
+			break
+		}
+	}
+	go func() {
+		for range events {
+		}
+	}()
+
+	block := []util.Cell{{X: 1, Y: 1}, {X: 2, Y: 1}, {X: 1, Y: 2}, {X: 2, Y: 2}}
+	vertical := append([]util.Cell{{X: 8, Y: 7}, {X: 8, Y: 8}, {X: 8, Y: 9}}, block...)
+	horizontal := append([]util.Cell{{X: 7, Y: 8}, {X: 8, Y: 8}, {X: 9, Y: 8}}, block...)
+	expected := vertical
+	if final.CompletedTurns%2 == 1 {
+		expected = horizontal
+	}
+	assertEqualBoard(t, final.Alive, expected, p)
+	var flipped []util.Cell
+	for cell, alive := range board {
+		if alive {
+			flipped = append(flipped, cell)
+		}
+	}
+	assertEqualBoard(t, flipped, expected, p)
+}
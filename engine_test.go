@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestEngine runs the shared engine, whose goroutines communicate through shared memory instead of
+// channels, on the boards of TestGol, checks that runs with tiles, a Margolus rule, a turmite and a
+// Generations rule give the same boards and events as the channel engine, and drives a run with keys and
+// an edit on both engines.
+func TestEngine(t *testing.T) {
+	for _, size := range []int{16, 64, 512} {
+		for _, turns := range []int{0, 1, 100} {
+			p := gol.Params{Turns: turns, ImageWidth: size, ImageHeight: size, Engine: "shared"}
+			expectedAlive := readAliveCells(fmt.Sprintf("check/images/%vx%vx%v.pgm", size, size, turns), size, size)
+			for _, threads := range []int{1, 2, 5, 8, 16} {
+				p.Threads = threads
+				t.Run(fmt.Sprintf("%dx%dx%d-%d", size, size, turns, threads), func(t *testing.T) {
+					alive, flipped := runTiled(p)
+					assertEqualBoard(t, alive, expectedAlive, p)
+					assertEqualBoard(t, flipped, expectedAlive, p)
+				})
+			}
+		}
+	}
+
+	random := gol.Generator{Kind: "random", Seed: 9, Density: 0.3}
+	runs := map[string]gol.Params{
+		"tiles":       {Turns: 30, Threads: 5, ImageWidth: 50, ImageHeight: 40, Generator: random, TileWidth: 7, TileHeight: 5},
+		"margolus":    {Turns: 30, Threads: 3, ImageWidth: 32, ImageHeight: 32, Generator: random, Rule: "Critters"},
+		"turmite":     {Turns: 300, Threads: 4, ImageWidth: 32, ImageHeight: 32, Generator: gol.Generator{Kind: "box"}, Turmite: "RL", Ants: []gol.Ant{{X: 5, Y: 5}, {X: 20, Y: 9}}},
+		"brain":       {Turns: 30, Threads: 6, ImageWidth: 40, ImageHeight: 40, Generator: random, Rule: "B2/S/C3"},
+		"autothreads": {Turns: 60, ImageWidth: 64, ImageHeight: 64, Generator: random},
+	}
+	for name, p := range runs {
+		t.Run(name, func(t *testing.T) {
+			expectedAlive, expectedFlipped := runTiled(p)
+			p.Engine = "shared"
+			alive, flipped := runTiled(p)
+			assertEqualBoard(t, alive, expectedAlive, p)
+			assertEqualBoard(t, flipped, expectedFlipped, p)
+		})
+	}
+
+	//keys and edits take a different path through each engine
+	for _, engine := range []string{"channels", "shared"} {
+		t.Run("keys/"+engine, func(t *testing.T) {
+			p := gol.Params{Turns: 100000000, Threads: 8, ImageWidth: 512, ImageHeight: 512, Engine: engine}
+			events := make(chan gol.Event)
+			keyPresses := make(chan rune, 10)
+			edits := make(chan gol.Edit)
+			p.OutDir = testOut
+			go gol.RunWithEdits(p, events, keyPresses, edits)
+			timeout := time.After(10 * time.Second)
+			// wait returns the first event that satisfies match.
+			wait := func(match func(gol.Event) bool) gol.Event {
+				for {
+					select {
+					case event := <-events:
+						if match(event) {
+							return event
+						}
+					case <-timeout:
+						t.Fatal("No matching event for 10s")
+					}
+				}
+			}
+
+			wait(func(e gol.Event) bool { _, ok := e.(gol.AliveCellsCount); return ok })
+			keyPresses <- 's'
+			wait(func(e gol.Event) bool { _, ok := e.(gol.ImageOutputComplete); return ok })
+			keyPresses <- 'p'
+			wait(func(e gol.Event) bool { s, ok := e.(gol.StateChange); return ok && s.NewState == gol.Paused })
+			go func() {
+				edits <- gol.Edit{Kind: gol.EditClear, Cell: util.Cell{X: 0, Y: 0}, Corner: util.Cell{X: 511, Y: 511}}
+			}()
+			wait(func(e gol.Event) bool { _, ok := e.(gol.WorldEdited); return ok })
+			keyPresses <- 'p'
+			wait(func(e gol.Event) bool { s, ok := e.(gol.StateChange); return ok && s.NewState == gol.Executing })
+			keyPresses <- 'q'
+			final := wait(func(e gol.Event) bool { _, ok := e.(gol.FinalTurnComplete); return ok }).(gol.FinalTurnComplete)
+			if len(final.Alive) != 0 {
+				t.Errorf("Expected the cleared board to stay empty, %d cells are alive", len(final.Alive))
+			}
+			wait(func(e gol.Event) bool { s, ok := e.(gol.StateChange); return ok && s.NewState == gol.Quitting })
+			for range events {
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestGenerations runs Generations rules on a random 64x64 world and checks the CellStateChanged events,
+// the final alive cells and the grey levels of the PGM output against a simple reference implementation.
+func TestGenerations(t *testing.T) {
+	rulestrings := map[string]string{
+		"B2/S/C3":    "B2/S/C3",    // Brian's Brain
+		"345/2/4":    "B2/S345/C4", // Star Wars
+		"B34/S12/C3": "B34/S12/C3", // Frogs
+		"B3/S23/C2":  "B3/S23",
+	}
+	for rulestring, expected := range rulestrings {
+		rule, err := gol.ParseRule(rulestring)
+		if err != nil || rule.String() != expected {
+			t.Errorf("ParseRule(%q) gave %v, %v, expected %v", rulestring, rule, err, expected)
+		}
+	}
+	for _, rulestring := range []string{"B3/S23/C1", "B3/S23/C257", "B3/S23/X4"} {
+		if _, err := gol.ParseRule(rulestring); err == nil {
+			t.Errorf("ParseRule(%q) should fail", rulestring)
+		}
+	}
+
+	for _, rulestring := range []string{"B2/S/C3", "B2/S345/C4", "B34/S12/C3"} {
+		for _, threads := range []int{1, 3, 8} {
+			p := gol.Params{Turns: 30, Threads: threads, ImageWidth: 64, ImageHeight: 64, Rule: rulestring,
+				Generator: gol.Generator{Kind: "random", Seed: 5, Density: 0.3}}
+			t.Run(fmt.Sprintf("%v-%d", rulestring, threads), func(t *testing.T) {
+				testGenerations(t, p)
+			})
+		}
+	}
+}
+
+func testGenerations(t *testing.T, p gol.Params) {
+	rule, _ := gol.ParseRule(p.Rule)
+	events := make(chan gol.Event)
+	p.OutDir = testOut
+	go gol.Run(p, events, nil)
+
+	states := make([][]int, p.ImageHeight)
+	for y := range states {
+		states[y] = make([]int, p.ImageWidth)
+	}
+	var final gol.FinalTurnComplete
+	for event := range events {
+		switch e := event.(type) {
+		case gol.CellStateChanged:
+			states[e.Cell.Y][e.Cell.X] = e.State
+		case gol.FinalTurnComplete:
+			final = e
+		}
+	}
+
+	expected := referenceGenerations(rule, states0(p), p.Turns)
+	for y := range expected {
+		for x := range expected[y] {
+			if states[y][x] != expected[y][x] {
+				t.Fatalf("Cell (%d, %d) is in state %d according to the events, expected %d", x, y, states[y][x], expected[y][x])
+			}
+		}
+	}
+
+	var expectedAlive []util.Cell
+	for y := range expected {
+		for x, state := range expected[y] {
+			if state == 1 {
+				expectedAlive = append(expectedAlive, util.Cell{X: x, Y: y})
+			}
+		}
+	}
+	assertEqualBoard(t, final.Alive, expectedAlive, p)
+
+	data, err := ioutil.ReadFile(outImage(fmt.Sprintf("%dx%dx%d", p.ImageWidth, p.ImageHeight, p.Turns)))
+	util.Check(err)
+	image := []byte(strings.Fields(string(data))[4])
+	for y := range expected {
+		for x, state := range expected[y] {
+			if got := rule.State(image[y*p.ImageWidth+x]); got != state {
+				t.Fatalf("Cell (%d, %d) of the PGM output is in state %d, expected %d", x, y, got, state)
+			}
+		}
+	}
+}
+
+// states0 gives the states of the initial world of p, which only has alive and dead cells.
+func states0(p gol.Params) [][]int {
+	events := make(chan gol.Event)
+	p.Turns = 0
+	p.OutDir = testOut
+	go gol.Run(p, events, nil)
+	states := make([][]int, p.ImageHeight)
+	for y := range states {
+		states[y] = make([]int, p.ImageWidth)
+	}
+	for event := range events {
+		if e, ok := event.(gol.CellFlipped); ok {
+			states[e.Cell.Y][e.Cell.X] = 1
+		}
+	}
+	return states
+}
+
+// referenceGenerations runs a Generations rule the simple way.
+func referenceGenerations(rule gol.Rule, states [][]int, turns int) [][]int {
+	height, width := len(states), len(states[0])
+	for turn := 0; turn < turns; turn++ {
+		next := make([][]int, height)
+		for y := range states {
+			next[y] = make([]int, width)
+			for x, state := range states[y] {
+				neighbours := 0
+				for j := -1; j <= 1; j++ {
+					for i := -1; i <= 1; i++ {
+						if (i != 0 || j != 0) && states[(y+j+height)%height][(x+i+width)%width] == 1 {
+							neighbours++
+						}
+					}
+				}
+				switch {
+				case state == 0 && rule.Birth[neighbours]:
+					next[y][x] = 1
+				case state == 0:
+					next[y][x] = 0
+				case state == 1 && rule.Survive[neighbours]:
+					next[y][x] = 1
+				default:
+					next[y][x] = (state + 1) % rule.States
+				}
+			}
+		}
+		states = next
+	}
+	return states
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestGenerator checks that generated worlds have the requested symmetry and that initial CellFlipped events are sent.
+func TestGenerator(t *testing.T) {
+	for _, kind := range []string{"C2", "C4", "D8"} {
+		p := gol.Params{ImageWidth: 64, ImageHeight: 64, Threads: 4,
+			Generator: gol.Generator{Kind: kind, Seed: 7, Density: 0.5, Size: 20}}
+		t.Run(kind, func(t *testing.T) {
+			flipped, alive := runGenerated(p)
+			if len(flipped) != len(alive) {
+				t.Errorf("Expected %d CellFlipped events for the initial world, got %d", len(alive), len(flipped))
+			}
+			cells := make(map[util.Cell]bool)
+			for _, cell := range alive {
+				cells[cell] = true
+				if cell.X < 22 || cell.X >= 42 || cell.Y < 22 || cell.Y >= 42 {
+					t.Fatalf("Cell %v is outside the 20x20 soup", cell)
+				}
+			}
+			for cell := range cells {
+				// coordinates relative to the centre of the soup, doubled so they stay whole numbers
+				x, y := 2*cell.X-63, 2*cell.Y-63
+				images := [][2]int{{-x, -y}}
+				if kind != "C2" {
+					images = append(images, [2]int{-y, x})
+				}
+				if kind == "D8" {
+					images = append(images, [2]int{-x, y}, [2]int{y, x})
+				}
+				for _, image := range images {
+					if !cells[util.Cell{X: (image[0] + 63) / 2, Y: (image[1] + 63) / 2}] {
+						t.Fatalf("%v soup is not symmetric: %v is alive but its image %v is not", kind, cell, image)
+					}
+				}
+			}
+		})
+	}
+
+	t.Run("pattern", func(t *testing.T) {
+		f, err := ioutil.TempFile("", "glider*.rle")
+		util.Check(err)
+		defer os.Remove(f.Name())
+		_, err = f.WriteString("#N Glider\nx = 3, y = 3, rule = B3/S23\nbob$2bo$3o!\n")
+		util.Check(err)
+		util.Check(f.Close())
+
+		p := gol.Params{ImageWidth: 16, ImageHeight: 16, Threads: 2, Turns: 4,
+			Generator: gol.Generator{Kind: "pattern", Pattern: f.Name(), X: 14, Y: 14}}
+		_, alive := runGenerated(p)
+		// after 4 turns the glider has moved one cell down and right, wrapping around the corner of the board
+		expected := []util.Cell{{X: 0, Y: 15}, {X: 1, Y: 0}, {X: 15, Y: 1}, {X: 0, Y: 1}, {X: 1, Y: 1}}
+		assertEqualBoard(t, alive, expected, p)
+	})
+
+	t.Run("pgm", func(t *testing.T) {
+		f, err := ioutil.TempFile("", "pattern*.pgm")
+		util.Check(err)
+		defer os.Remove(f.Name())
+		//state 23 of 24 is stored as 13, a carriage return, which must not be taken for the end of a field
+		_, err = f.Write(append([]byte("P5\n# made by hand\n4 2 # width and height\n255\n"),
+			0xFF, 13, 0, 0xFF, 0, 0xFF, 13, 0))
+		util.Check(err)
+		util.Check(f.Close())
+
+		p := gol.Params{ImageWidth: 8, ImageHeight: 8, Threads: 2, Rule: "B2/S/C24",
+			Generator: gol.Generator{Kind: "pattern", Pattern: f.Name(), X: 2, Y: 3}}
+		events := make(chan gol.Event)
+		p.OutDir = testOut
+		go gol.Run(p, events, nil)
+		states := make(map[util.Cell]int)
+		var alive []util.Cell
+		for event := range events {
+			switch e := event.(type) {
+			case gol.CellStateChanged:
+				states[e.Cell] = e.State
+			case gol.FinalTurnComplete:
+				alive = e.Alive
+			}
+		}
+		assertEqualBoard(t, alive, []util.Cell{{X: 2, Y: 3}, {X: 5, Y: 3}, {X: 3, Y: 4}}, p)
+		for _, cell := range []util.Cell{{X: 3, Y: 3}, {X: 4, Y: 4}} {
+			if states[cell] != 23 {
+				t.Errorf("Expected cell %v to be in state 23, got %d", cell, states[cell])
+			}
+		}
+	})
+}
+
+func runGenerated(p gol.Params) ([]util.Cell, []util.Cell) {
+	events := make(chan gol.Event)
+	p.OutDir = testOut
+	go gol.Run(p, events, nil)
+	var flipped, alive []util.Cell
+	for event := range events {
+		switch e := event.(type) {
+		case gol.CellFlipped:
+			if e.CompletedTurns == 0 {
+				flipped = append(flipped, e.Cell)
+			}
+		case gol.FinalTurnComplete:
+			alive = e.Alive
+		}
+	}
+	return flipped, alive
+}
@@ -0,0 +1,278 @@
+package gol
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// Policy is what the buffer of a subscriber to a Bus does when it is full.
+type Policy int
+
+const (
+	// Block makes the engine wait for the subscriber to take an event, like the events channel of Run.
+	Block Policy = iota
+	// DropOldest drops the oldest event in the buffer to make room for the new one, so the engine never
+	// waits for a subscriber that falls behind, and the subscriber gets the latest events.
+	DropOldest
+	// Coalesce merges the new event into one in the buffer that it supersedes: a CellFlipped cancels out
+	// the buffered CellFlipped of the same cell, a CellStateChanged replaces that of the same cell, and a
+	// TurnComplete, AliveCellsCount, AntsMoved, ThreadsChanged or TurnStats replaces the buffered one of its
+	// kind. The cells built from the events still end up the same. Other events, and events with nothing to
+	// merge into, make the engine wait like Block once the buffer is full.
+	Coalesce
+)
+
+// Bus passes the events of a run to any number of subscribers, each with its own filter and buffer, see
+// RunWithBus. Events are only made if a subscriber takes them, so a run whose subscribers do not take
+// CellFlipped does not report a single flipped cell.
+type Bus struct {
+	// subscribers holds a []*subscriber, replaced as a whole on every Subscribe so that publishing, which the
+	// workers do for every flipped cell, never takes a lock.
+	subscribers atomic.Value
+	mutex       sync.Mutex // held while subscribing or closing
+	closed      bool
+}
+
+// subscriber is one subscription to a Bus.
+type subscriber struct {
+	events chan<- Event
+	// buffer is the channel made by Subscribe, which DropOldest drops events from, nil for attach.
+	buffer chan Event
+	policy Policy
+	// kinds are the types of the events taken, every event if nil.
+	kinds map[reflect.Type]bool
+	// queue holds the events of Coalesce, which cannot be merged once they are in a channel.
+	queue *coalesceQueue
+}
+
+// NewBus makes a Bus without subscribers.
+func NewBus() *Bus {
+	b := &Bus{}
+	b.subscribers.Store([]*subscriber(nil))
+	return b
+}
+
+// Subscribe gives a channel of the events whose types are those of kinds, e.g. gol.TurnComplete{}, or of
+// every event if there are no kinds. The channel buffers size events with policy, at least one, and is
+// closed after the last event of the run. Subscribe before the run starts to get every event.
+func (b *Bus) Subscribe(size int, policy Policy, kinds ...Event) <-chan Event {
+	if size < 1 {
+		//DropOldest needs an event in the buffer to drop, or it could never make room for the new one
+		size = 1
+	}
+	s := &subscriber{buffer: make(chan Event, size), policy: policy}
+	if len(kinds) > 0 {
+		s.kinds = make(map[reflect.Type]bool)
+		for _, kind := range kinds {
+			s.kinds[reflect.TypeOf(kind)] = true
+		}
+	}
+	if policy == Coalesce {
+		//the queue is the buffer, and the channel only hands its events over
+		s.buffer = make(chan Event)
+		s.queue = newCoalesceQueue(size)
+		go s.queue.forward(s.buffer)
+	}
+	s.events = s.buffer
+	b.add(s)
+	return s.buffer
+}
+
+// attach makes events a subscriber that takes every event and blocks, which is what Run does with its
+// events channel.
+func (b *Bus) attach(events chan<- Event) {
+	b.add(&subscriber{events: events, policy: Block})
+}
+
+func (b *Bus) add(s *subscriber) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.closed {
+		s.close()
+		return
+	}
+	subscribers := b.subscribers.Load().([]*subscriber)
+	b.subscribers.Store(append(append([]*subscriber(nil), subscribers...), s))
+}
+
+// takes tells whether the subscriber takes events of type kind.
+func (s *subscriber) takes(kind reflect.Type) bool {
+	return s.kinds == nil || s.kinds[kind]
+}
+
+// takes tells whether any subscriber takes events of type kind. A nil Bus takes nothing.
+func (b *Bus) takes(kind reflect.Type) bool {
+	if b == nil {
+		return false
+	}
+	for _, s := range b.subscribers.Load().([]*subscriber) {
+		if s.takes(kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// Publish passes an event to every subscriber that takes it.
+func (b *Bus) Publish(event Event) {
+	kind := reflect.TypeOf(event)
+	for _, s := range b.subscribers.Load().([]*subscriber) {
+		if s.takes(kind) {
+			s.publish(event)
+		}
+	}
+}
+
+func (s *subscriber) publish(event Event) {
+	switch s.policy {
+	case DropOldest:
+		for {
+			select {
+			case s.events <- event:
+				return
+			default:
+			}
+			//the subscriber may take the oldest event first, in which case there is room anyway
+			select {
+			case <-s.buffer:
+			default:
+			}
+		}
+	case Coalesce:
+		s.queue.push(event)
+	default:
+		s.events <- event
+	}
+}
+
+// Close closes the channels of the subscribers once they have been given every event published before.
+func (b *Bus) Close() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.closed = true
+	for _, s := range b.subscribers.Load().([]*subscriber) {
+		s.close()
+	}
+}
+
+func (s *subscriber) close() {
+	if s.queue != nil {
+		s.queue.close()
+	} else {
+		close(s.events)
+	}
+}
+
+// Types of the events that are made once per cell, which are only made if a subscriber takes them.
+var (
+	cellFlippedType      = reflect.TypeOf(CellFlipped{})
+	cellStateChangedType = reflect.TypeOf(CellStateChanged{})
+)
+
+// coalesceKey is what an event in a coalesceQueue supersedes: the event with the same key.
+type coalesceKey struct {
+	kind reflect.Type
+	cell util.Cell
+}
+
+// coalesceQueue is the buffer of a Coalesce subscriber.
+type coalesceQueue struct {
+	mutex sync.Mutex
+	cond  *sync.Cond
+	size  int
+	// queue holds the events in order, with nil in place of those that were merged away. queue[0] is event
+	// number first of all that were pushed, so that the numbers in last stay right as events are taken.
+	// last holds the number of the event in the queue with each key.
+	queue  []Event
+	first  int
+	live   int
+	last   map[coalesceKey]int
+	closed bool
+}
+
+func newCoalesceQueue(size int) *coalesceQueue {
+	q := &coalesceQueue{size: size, last: make(map[coalesceKey]int)}
+	q.cond = sync.NewCond(&q.mutex)
+	return q
+}
+
+// keyOf gives the key of an event, and false for the events that are never merged.
+func keyOf(event Event) (coalesceKey, bool) {
+	kind := reflect.TypeOf(event)
+	switch e := event.(type) {
+	case CellFlipped:
+		return coalesceKey{kind, e.Cell}, true
+	case CellStateChanged:
+		return coalesceKey{kind, e.Cell}, true
+	case TurnComplete, AliveCellsCount, AntsMoved, ThreadsChanged, TurnStats:
+		return coalesceKey{kind: kind}, true
+	}
+	return coalesceKey{}, false
+}
+
+// push adds an event to the queue, merging it into the event it supersedes, and waiting for room if there
+// is nothing to merge into and the queue is full. As every event is merged on the way in, the queue never
+// holds two events with the same key.
+func (q *coalesceQueue) push(event Event) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	key, merges := keyOf(event)
+	if i, ok := q.last[key]; merges && ok {
+		q.queue[i-q.first] = nil
+		delete(q.last, key)
+		q.live--
+		if _, flipped := event.(CellFlipped); flipped {
+			//two flips of the same cell leave it as it was
+			return
+		}
+	}
+	for q.live >= q.size {
+		q.cond.Wait()
+	}
+	if merges {
+		q.last[key] = q.first + len(q.queue)
+	}
+	q.queue = append(q.queue, event)
+	q.live++
+	q.cond.Broadcast()
+}
+
+// close marks the end of the events: the channel is closed once the queue is empty.
+func (q *coalesceQueue) close() {
+	q.mutex.Lock()
+	q.closed = true
+	q.cond.Broadcast()
+	q.mutex.Unlock()
+}
+
+// forward sends the events of the queue to events one at a time, so that the events still in the queue can
+// be merged until they are sent.
+func (q *coalesceQueue) forward(events chan<- Event) {
+	for {
+		q.mutex.Lock()
+		for q.live == 0 && !q.closed {
+			q.cond.Wait()
+		}
+		if q.live == 0 {
+			q.mutex.Unlock()
+			close(events)
+			return
+		}
+		var event Event
+		for event == nil {
+			event = q.queue[0]
+			q.queue = q.queue[1:]
+			q.first++
+		}
+		if key, ok := keyOf(event); ok && q.last[key] == q.first-1 {
+			delete(q.last, key)
+		}
+		q.live--
+		q.cond.Broadcast()
+		q.mutex.Unlock()
+		events <- event
+	}
+}
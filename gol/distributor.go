@@ -2,34 +2,194 @@ package gol
 
 import (
 	"fmt"
-	"os"
 	"time"
 	"uk.ac.bris.cs/gameoflife/util"
 )
 
 type distributorChannels struct {
-	events    chan<- Event
+	events    *Bus
 	ioCommand chan<- ioCommand
 	ioIdle    <-chan bool
 
 	ioFilename   chan<- string
+	ioSize       chan<- int // the width and then the height of a world to output
 	ioOutput     chan<- uint8
 	ioInput      <-chan uint8
+	ioStats      chan<- TurnStats
 	ioKeyPresses <-chan rune
+	edits        <-chan Edit
+
+	shared *sharedState // set for the shared engine, which goes through it instead of any of the channels
 }
 
-func initialiseWorld(p Params, c distributorChannels) [][]uint8 {
-	count := 0
+// send reports an event, unless nobody is listening for events.
+func (c distributorChannels) send(event Event) {
+	if c.shared != nil {
+		c.shared.events.push(event)
+	} else if c.events != nil {
+		c.events.Publish(event)
+	}
+}
+
+// closeEvents ends the events once every event has been sent.
+func (c distributorChannels) closeEvents() {
+	if c.shared != nil {
+		c.shared.events.close()
+	} else {
+		c.events.Close()
+	}
+}
+
+// input is something for the distributor to act on between turns: a tick of the 2 second ticker,
+// an edit or else a key press.
+type input struct {
+	tick bool
+	key  rune
+	edit *Edit
+}
+
+// nextInput gives the next key press or edit, waiting for one if block is set. Without block it can also
+// give a tick of ticker, and gives nothing if there is nothing to act on.
+func (c distributorChannels) nextInput(ticker <-chan time.Time, block bool) (input, bool) {
+	if c.shared != nil {
+		return c.shared.input.next(block)
+	}
+	if block {
+		select {
+		case key := <-c.ioKeyPresses:
+			return input{key: key}, true
+		case edit := <-c.edits:
+			return input{edit: &edit}, true
+		}
+	}
+	select {
+	case <-ticker:
+		return input{tick: true}, true
+	case key := <-c.ioKeyPresses:
+		return input{key: key}, true
+	case edit := <-c.edits:
+		return input{edit: &edit}, true
+	default:
+		return input{}, false
+	}
+}
+
+// readWorld asks the io goroutine for the cells of the world named filename, size of them row by row.
+func (c distributorChannels) readWorld(filename string, size int) []uint8 {
+	if c.shared != nil {
+		c.shared.io.request(ioInput, filename, nil, TurnStats{})
+		return c.shared.io.wait()
+	}
 	c.ioCommand <- ioInput
-	c.ioFilename <- fmt.Sprintf("%dx%d", p.ImageWidth, p.ImageHeight)
+	c.ioFilename <- filename
+	cells := make([]uint8, size)
+	for i := range cells {
+		cells[i] = <-c.ioInput
+	}
+	return cells
+}
+
+// writeWorld asks the io goroutine to write the world to filename.pgm in Params.OutDir. The world does not
+// have to be the size of the board, e.g. the bounds of the unbounded plane.
+func (c distributorChannels) writeWorld(filename string, world [][]uint8) {
+	if c.shared != nil {
+		copied := make([][]uint8, len(world))
+		for y, row := range world {
+			copied[y] = append([]uint8(nil), row...)
+		}
+		c.shared.io.request(ioOutput, filename, copied, TurnStats{})
+		return
+	}
+	c.ioCommand <- ioOutput
+	c.ioFilename <- filename
+	c.ioSize <- len(world[0])
+	c.ioSize <- len(world)
+	for _, y := range world {
+		for _, x := range y {
+			c.ioOutput <- x
+		}
+	}
+}
+
+// writeStats asks the io goroutine to append the stats of a turn to the stats file.
+func (c distributorChannels) writeStats(stats TurnStats) {
+	if c.shared != nil {
+		c.shared.io.request(ioStats, "", nil, stats)
+		return
+	}
+	c.ioCommand <- ioStats
+	c.ioStats <- stats
+}
+
+// waitIo waits for the io goroutine to finish any output.
+func (c distributorChannels) waitIo() {
+	if c.shared != nil {
+		c.shared.io.request(ioCheckIdle, "", nil, TurnStats{})
+		c.shared.io.wait()
+		return
+	}
+	c.ioCommand <- ioCheckIdle
+	<-c.ioIdle
+}
+
+// stopIo waits for the io goroutine to finish any output, closes the stats file and stops the io goroutine,
+// once the run has ended.
+func (c distributorChannels) stopIo() {
+	if c.shared != nil {
+		c.shared.io.request(ioQuit, "", nil, TurnStats{})
+		c.shared.io.wait()
+		return
+	}
+	c.ioCommand <- ioQuit
+	<-c.ioIdle
+}
+
+// join gives a done to be called by each of workers workers and a wait that returns once they all have.
+func (c distributorChannels) join(workers int) (func(), func()) {
+	if c.shared != nil {
+		return newSharedJoin(workers)
+	}
+	finished := make(chan bool)
+	done := func() {
+		finished <- true
+	}
+	wait := func() {
+		for i := 0; i < workers; i++ {
+			<-finished
+		}
+	}
+	return done, wait
+}
+
+// cellFlipped reports a flipped cell, unless nobody is listening for CellFlipped events.
+func (c distributorChannels) cellFlipped(turn int, cell util.Cell) {
+	if c.events.takes(cellFlippedType) {
+		c.send(CellFlipped{CompletedTurns: turn, Cell: cell})
+	}
+}
+
+// cellChanged reports a cell that went from value before to value after: a CellFlipped if it became alive
+// or stopped being alive, and a CellStateChanged if the rule has more than two states.
+func (c distributorChannels) cellChanged(turn int, cell util.Cell, before, after uint8, rule Rule) {
+	if (before == 0xFF) != (after == 0xFF) {
+		c.cellFlipped(turn, cell)
+	}
+	if rule.states() > 2 && before != after && c.events.takes(cellStateChangedType) {
+		c.send(CellStateChanged{CompletedTurns: turn, Cell: cell, State: rule.State(after)})
+	}
+}
+
+func initialiseWorld(p Params, rule Rule, c distributorChannels) [][]uint8 {
+	count := 0
+	cells := c.readWorld(fmt.Sprintf("%dx%d", p.ImageWidth, p.ImageHeight), p.ImageWidth*p.ImageHeight)
 	world := make([][]uint8, p.ImageHeight)
 	//initialising world
 	for row := range world {
 		world[row] = make([]byte, p.ImageWidth)
 		for x := 0; x < p.ImageWidth; x++ {
-			world[row][x] = <-c.ioInput
+			world[row][x] = rule.normalise(cells[row*p.ImageWidth+x])
 			if world[row][x] != 0 {
-				c.events <- CellFlipped{CompletedTurns: 0, Cell: util.Cell{X: x, Y: row}}
+				c.cellChanged(0, util.Cell{X: x, Y: row}, 0, world[row][x], rule)
 				count += 1
 			}
 		}
@@ -42,12 +202,12 @@ func countNeighbour(topEdge []uint8, botEdge []uint8, world [][]uint8, neighbour
 	width := len(topEdge)
 	//handle the  row above and the row below
 	for i := 0; i < width; i++ {
-		if topEdge[i] != 0 {
+		if topEdge[i] == 0xFF {
 			neighbours[0][(i+width-1)%width] += 1
 			neighbours[0][i] += 1
 			neighbours[0][(i+width+1)%width] += 1
 		}
-		if botEdge[i] != 0 {
+		if botEdge[i] == 0xFF {
 			neighbours[height-1][(i+width-1)%width] += 1
 			neighbours[height-1][i] += 1
 			neighbours[height-1][(i+width+1)%width] += 1
@@ -56,7 +216,8 @@ func countNeighbour(topEdge []uint8, botEdge []uint8, world [][]uint8, neighbour
 	//handle middle rows
 	for y, row := range world {
 		for x, cell := range row {
-			if cell != 0 {
+			//only alive cells count, not the dying ones of rules with more than two states
+			if cell == 0xFF {
 				//only one row
 				if y == 0 && y == height-1 {
 					for i := -1; i <= 1; i++ {
@@ -89,41 +250,68 @@ func countNeighbour(topEdge []uint8, botEdge []uint8, world [][]uint8, neighbour
 	}
 }
 
-func worldAfterOneTurn(width int, pieceOfWorld [][]uint8, topEdge []uint8, botEdge []uint8, startY int, c distributorChannels, turn int) [][]uint8 {
+// halo gives the depth rows above row startY and the depth rows below row endY, wrapping around the edges of the world.
+func halo(world [][]uint8, startY, endY, depth int) ([][]uint8, [][]uint8) {
+	height := len(world)
+	above := make([][]uint8, depth)
+	below := make([][]uint8, depth)
+	for i := 0; i < depth; i++ {
+		above[i] = world[((startY-depth+i)%height+height)%height]
+		below[i] = world[(endY+1+i)%height]
+	}
+	return above, below
+}
+
+// worldAfterOneTurn computes the next state of pieceOfWorld. above and below are the rows of halo around it,
+// as many as rule.depth(), with the rows next to the piece last in above and first in below.
+// If active is set only the active tiles of the piece are computed, see activity, and if the rule has a
+// lookup table the piece is computed 2x2 cells at a time, see lookupTurn.
+func worldAfterOneTurn(width int, pieceOfWorld [][]uint8, above [][]uint8, below [][]uint8, startY int, rule Rule, c distributorChannels, turn int, active, changed [][]bool) [][]uint8 {
+	if active != nil {
+		return sparseTurn(width, pieceOfWorld, above[len(above)-1], below[0], startY, rule, c, turn, active, changed)
+	}
+	if rule.lookup != nil {
+		return lookupTurn(width, pieceOfWorld, above[len(above)-1], below[0], startY, rule, c, turn)
+	}
 	//make newWorld to record the state after one turn
-	newWorld := make([][]uint8, len(pieceOfWorld), width)
-	neighbourCounts := make([][]int, len(pieceOfWorld), width)
+	newWorld := make([][]uint8, len(pieceOfWorld))
+	var neighbourCounts [][]int
 	for i := 0; i < len(pieceOfWorld); i++ {
 		newWorld[i] = make([]uint8, width)
-		neighbourCounts[i] = make([]int, width)
-		copy(newWorld[i], pieceOfWorld[i])
 	}
-	countNeighbour(topEdge, botEdge, pieceOfWorld, neighbourCounts) //count alive neighbours of the previous pieceOfWorld 			//index for newWorld created
+	if rule.largerThanLife() {
+		rows := append(append(append([][]uint8{}, above...), pieceOfWorld...), below...)
+		neighbourCounts = countLargerThanLife(rows, rule)
+	} else if rule.isotropic() {
+		neighbourCounts = configurations(above[len(above)-1], below[0], pieceOfWorld)
+	} else if rule.Neighbourhood == VonNeumann || rule.Neighbourhood == Hexagonal {
+		neighbourCounts = countGrid(above[len(above)-1], below[0], pieceOfWorld, startY, rule.Neighbourhood)
+	} else {
+		neighbourCounts = make([][]int, len(pieceOfWorld))
+		for i := range neighbourCounts {
+			neighbourCounts[i] = make([]int, width)
+		}
+		topEdge, botEdge := above[len(above)-1], below[0]
+		countNeighbour(topEdge, botEdge, pieceOfWorld, neighbourCounts) //count alive neighbours of the previous pieceOfWorld
+	}
 	for h := 0; h < len(pieceOfWorld); h++ {
 		for w := 0; w < width; w++ {
-			if (neighbourCounts[h][w] < 2 || neighbourCounts[h][w] > 3) && pieceOfWorld[h][w] != 0 {
-				newWorld[h][w] = 0
+			newWorld[h][w] = rule.next(pieceOfWorld[h][w], neighbourCounts[h][w])
+			if rule.noise.active() {
+				newWorld[h][w] = rule.noise.apply(pieceOfWorld[h][w], newWorld[h][w], turn, w, startY+h, rule)
+			}
+			if newWorld[h][w] != pieceOfWorld[h][w] {
 				//report the flip of the cell
 				//startY + h making sure its reporting global location
-				c.events <- CellFlipped{CompletedTurns: turn, Cell: util.Cell{X: w, Y: startY + h}}
-
-			} else if neighbourCounts[h][w] == 3 && pieceOfWorld[h][w] == 0 {
-				newWorld[h][w] = 0xFF
-				//report the flip of the cell
-				c.events <- CellFlipped{CompletedTurns: turn, Cell: util.Cell{X: w, Y: startY + h}}
-
-			} else if pieceOfWorld[h][w] == 0xFF {
-				newWorld[h][w] = 0xFF
-			} else {
-				newWorld[h][w] = 0
+				c.cellChanged(turn, util.Cell{X: w, Y: startY + h}, pieceOfWorld[h][w], newWorld[h][w], rule)
 			}
 		}
 	}
 	return newWorld
 }
 
-func worker(width int, pieceOfWorld [][]uint8, topEdge []uint8, botEdge []uint8, startY int, outChain chan<- [][]uint8, c distributorChannels, turn int) {
-	outChain <- worldAfterOneTurn(width, pieceOfWorld, topEdge, botEdge, startY, c, turn)
+func worker(width int, pieceOfWorld [][]uint8, above [][]uint8, below [][]uint8, startY int, rule Rule, outChain chan<- [][]uint8, c distributorChannels, turn int, active, changed [][]bool) {
+	outChain <- worldAfterOneTurn(width, pieceOfWorld, above, below, startY, rule, c, turn, active, changed)
 }
 
 func computeAliveCell(world [][]uint8) []util.Cell {
@@ -141,98 +329,282 @@ func computeAliveCell(world [][]uint8) []util.Cell {
 
 // send the current state to the IO channel for output a PGM output file.
 func currentState(p Params, world [][]byte, currentTurn int, c distributorChannels) {
-	c.ioCommand <- ioOutput
-	c.ioFilename <- fmt.Sprintf("%dx%dx%d", p.ImageWidth, p.ImageHeight, currentTurn)
-	for _, y := range world {
-		for _, x := range y {
-			c.ioOutput <- x
-		}
+	filename := fmt.Sprintf("%dx%dx%d", p.ImageWidth, p.ImageHeight, currentTurn)
+	c.writeWorld(filename, world)
+	// Wait for the file to be written before reporting it.
+	c.waitIo()
+	c.send(ImageOutputComplete{CompletedTurns: currentTurn, Filename: filename})
+}
+
+// checkBoard tells whether rule can run on the board of p, once a turmite has taken the place of the rule.
+func checkBoard(p Params, rule Rule) error {
+	if tiled(p) && (p.Turmite != "" || rule.margolus()) {
+		//turmites and Margolus rules take their own path through the world every turn, which tiles would not change
+		return fmt.Errorf("turmites and Margolus rules cannot be split into tiles")
+	}
+	if p.Unbounded {
+		return unbounded(p, rule)
+	}
+	if rule.Neighbourhood == Hexagonal && p.ImageHeight%2 != 0 {
+		return fmt.Errorf("hexagonal rule %v needs a board with an even height, not %d", rule, p.ImageHeight)
+	}
+	if side := 2*rule.Range + 1; rule.largerThanLife() && (p.ImageWidth < side || p.ImageHeight < side) {
+		//a neighbourhood that wraps all the way around the board would count some cells twice
+		return fmt.Errorf("rule %v needs a board of at least %dx%d, not %dx%d", rule, side, side, p.ImageWidth, p.ImageHeight)
 	}
+	if rule.margolus() && (p.ImageWidth%2 != 0 || p.ImageHeight%2 != 0) {
+		return fmt.Errorf("Margolus rule %v needs a board with an even width and height, not %dx%d", rule, p.ImageWidth, p.ImageHeight)
+	}
+	return nil
 }
 
 // distributor divides the work between workers and interacts with other goroutines.
 func distributor(p Params, c distributorChannels) {
+	rule, err := ParseRule(p.Rule)
+	util.Check(err)
+	rule.noise = p.Noise
+	if p.Lookup {
+		rule.lookup, err = lookupTable(rule)
+		util.Check(err)
+	}
+	//a turmite takes the place of the rule, and its colours the place of the states
+	var turmite Turmite
+	var ants []Ant
+	if p.Turmite != "" {
+		turmite, err = ParseTurmite(p.Turmite)
+		util.Check(err)
+		rule = turmite.colourRule()
+		ants, err = startAnts(p, turmite)
+		util.Check(err)
+	}
+	util.Check(checkBoard(p, rule))
+	//generation counts the turns from the loaded world, going down while a Margolus rule runs backwards,
+	//so that the blocks of every turn straddle those of the turn before in either direction
+	generation := 0
+	backwards := false
+	var inverse []int
+	if rule.margolus() {
+		inverse, err = rule.inverse()
+		if p.Reverse {
+			util.Check(err)
+			backwards, generation = true, p.Turns
+		}
+	}
 	//Create a 2D slice to store the world.
-	world := initialiseWorld(p, c)
+	world := initialiseWorld(p, rule, c)
 	turn := 0
-	tickerChan := time.NewTicker(2 * time.Second)
+	//pl takes the place of the world on the unbounded plane, nil on a board that wraps around
+	var pl *plane
+	if p.Unbounded {
+		pl = newPlane(world)
+	}
+	snapshot := func() {
+		if pl != nil {
+			planeState(pl, turn, c)
+		} else {
+			currentState(p, world, turn, c)
+		}
+	}
+	//tiles keeps track of the parts of the world that are still changing, nil if every cell is computed every turn
+	var tiles *activity
+	if sparse(p, rule) {
+		tiles = newActivity(p.ImageWidth, p.ImageHeight)
+	}
+	var active, changed [][]bool
+	var tileQueue []tile
+	if tiled(p) {
+		tileQueue = splitTiles(p.ImageWidth, p.ImageHeight, p.TileWidth, p.TileHeight)
+	}
+	if ants != nil {
+		c.send(AntsMoved{CompletedTurns: turn, Ants: append([]Ant(nil), ants...)})
+	}
+	//the shared engine keeps time itself instead of a ticker, which has a channel
+	var tick <-chan time.Time
+	if c.shared == nil {
+		tickerChan := time.NewTicker(2 * time.Second)
+		defer tickerChan.Stop()
+		tick = tickerChan.C
+	}
 	//Execute all turns of the Game of Life.
 	var newWorld [][]uint8 //a world that's keep been updated
-	var key rune
 	var startY, endY, extraWorkLeft, thread int
 	var distributedWorld [][]uint8
-	var topEdge, botEdge []uint8
+	var above, below [][]uint8
 	var outChainForWorker chan [][]uint8
 	//making out channels for workers to pass their output
+	//threads is the number of workers of the next turn, chosen by tune as the run goes if p.Threads is 0
+	threads := p.Threads
+	var tune *tuner
+	if threads <= 0 {
+		tune = newTuner(p.ImageHeight)
+		threads = tune.threads()
+	}
 	var outChannels []chan [][]uint8 //list of channels potentially contains the output from each worker
-	for i := 0; i < p.Threads; i++ {
+	for i := 0; i < threads || (tune != nil && i < tune.most()); i++ {
 		outChan := make(chan [][]uint8)
 		outChannels = append(outChannels, outChan)
 	}
 
-	for turn < p.Turns {
-		select {
-		case <-tickerChan.C:
-			c.events <- AliveCellsCount{CompletedTurns: turn, CellsCount: len(computeAliveCell(world))}
-
-		case key = <-c.ioKeyPresses:
-			switch key {
-			case 'p':
+	quit := false
+	paused := false
+	handleKey := func(key rune) {
+		switch key {
+		case 'p':
+			paused = !paused
+			if paused {
 				fmt.Println("Current turn:", turn)
-				for {
-					if <-c.ioKeyPresses == 'p' {
-						fmt.Println("Continuing")
-						break
+				c.send(StateChange{turn, Paused})
+			} else {
+				fmt.Println("Continuing")
+				c.send(StateChange{turn, Executing})
+			}
+		case 'q':
+			//finish like the last turn was reached, so the PGM file is output and the events channel closed
+			quit = true
+		case 's':
+			snapshot()
+		case 'b':
+			switch {
+			case !rule.margolus():
+				fmt.Println("Only Margolus rules can run backwards")
+			case inverse == nil:
+				fmt.Println("Rule", rule, "is not reversible")
+			default:
+				backwards = !backwards
+				fmt.Println("Running backwards:", backwards)
+			}
+		}
+	}
+
+	for turn < p.Turns && !quit {
+		//while paused nothing happens until the next key press or edit
+		in, ok := c.nextInput(tick, paused)
+		switch {
+		case in.tick:
+			if pl != nil {
+				c.send(AliveCellsCount{CompletedTurns: turn, CellsCount: pl.count()})
+			} else {
+				c.send(AliveCellsCount{CompletedTurns: turn, CellsCount: len(computeAliveCell(world))})
+			}
+
+		case in.edit != nil:
+			if pl != nil {
+				in.edit.apply(pl, turn, rule, c)
+			} else {
+				in.edit.apply(torus(world), turn, rule, c)
+			}
+			if tiles != nil {
+				tiles.all()
+			}
+		case ok:
+			handleKey(in.key)
+		default:
+			turnStart := time.Now()
+			if pl != nil {
+				pl = pl.step(rule, threads, c, turn)
+			} else if ants != nil {
+				newWorld = turmite.moveAnts(ants, world, threads, turn, rule, c)
+				c.send(AntsMoved{CompletedTurns: turn + 1, Ants: append([]Ant(nil), ants...)})
+			} else if rule.margolus() {
+				table := rule.Margolus
+				if backwards {
+					//undo the turn that made this generation, with the blocks it used
+					generation--
+					table = inverse
+				}
+				newWorld = margolusTurn(world, (generation%2+2)%2, table, threads, turn, c)
+				if !backwards {
+					generation++
+				}
+			} else if tiled(p) {
+				newWorld = tiledTurn(world, tileQueue, threads, rule, c, turn)
+			} else {
+				var pieces [][][]uint8
+				var done, wait func()
+				if c.shared != nil {
+					pieces = make([][][]uint8, threads)
+					done, wait = c.join(threads)
+				}
+				startY = 0
+				extraWorkLeft = p.ImageHeight % threads //if work cannot be split equally, keep track of number of extra work left and assign to worker
+				//Assign works to worker threads
+				for thread = 0; thread < threads; thread++ {
+					endY = startY + (p.ImageHeight / threads) - 1 //end = start + amount it suppose to do, -1 for start from 0
+					if extraWorkLeft > 0 {
+						endY += 1 //assign extra work to this worker
+						extraWorkLeft--
 					}
+					outChainForWorker = outChannels[thread]
+					//these are all passed by reference
+					distributedWorld = world[startY : endY+1]              //only give the slice of the world that assigned to this worker
+					above, below = halo(world, startY, endY, rule.depth()) //give the rows above and below its piece of world
+					if tiles != nil {
+						active, changed = tiles.active[startY:endY+1], tiles.changed[startY:endY+1]
+					}
+
+					if c.shared != nil {
+						//the shared engine leaves the pieces in a slice instead of sending them back
+						go func(thread int, distributedWorld, above, below [][]uint8, startY int, active, changed [][]bool) {
+							pieces[thread] = worldAfterOneTurn(p.ImageWidth, distributedWorld, above, below, startY, rule, c, turn, active, changed)
+							done()
+						}(thread, distributedWorld, above, below, startY, active, changed)
+					} else {
+						go worker(p.ImageWidth, distributedWorld, above, below, startY, rule, outChainForWorker, c, turn, active, changed)
+					}
+					startY = endY + 1 //prepare for next worker
+				}
+				if c.shared != nil {
+					wait()
+					for thread = 0; thread < threads; thread++ {
+						newWorld = append(newWorld, pieces[thread]...)
+					}
+				} else {
+					for thread = 0; thread < threads; thread++ { //combining pieces of result to a new world
+						newWorld = append(newWorld, <-outChannels[thread]...)
+					}
+				}
+				if tiles != nil {
+					tiles.update()
 				}
-			case 'q':
-				currentState(p, world, turn, c)
-				os.Exit(0)
-			case 's':
-				currentState(p, world, turn, c)
 			}
-		default:
-			startY = 0
-			extraWorkLeft = p.ImageHeight % p.Threads //if work cannot be split equally, keep track of number of extra work left and assign to worker
-			//Assign works to worker threads
-			for thread = 0; thread < p.Threads; thread++ {
-				endY = startY + (p.ImageHeight / p.Threads) - 1 //end = start + amount it suppose to do, -1 for start from 0
-				if extraWorkLeft > 0 {
-					endY += 1 //assign extra work to this worker
-					extraWorkLeft--
+			turnDuration := time.Since(turnStart)
+			turn += 1
+			c.send(TurnComplete{CompletedTurns: turn}) //Report the new state using Event.
+			if tune != nil {
+				if tune.record(turnDuration) {
+					c.send(ThreadsChanged{CompletedTurns: turn, Threads: tune.threads()})
 				}
-				outChainForWorker = outChannels[thread]
-				//these are all passed by reference
-				distributedWorld = world[startY : endY+1]               //only give the slice of the world that assigned to this worker
-				topEdge = world[(startY+p.ImageHeight-1)%p.ImageHeight] //give the row above its piece of world
-				botEdge = world[(endY+1+p.ImageHeight)%p.ImageHeight]   //give the row below its piece of world
-
-				go worker(p.ImageWidth, distributedWorld, topEdge, botEdge, startY, outChainForWorker, c, turn)
-				startY = endY + 1 //prepare for next worker
+				threads = tune.threads()
 			}
-			for thread = 0; thread < p.Threads; thread++ { //combining pieces of result to a new world
-				newWorld = append(newWorld, <-outChannels[thread]...)
+			if p.Stats || p.StatsFile != "" {
+				stats := turnStats(world, newWorld, turn, turnDuration)
+				if p.StatsFile != "" {
+					c.writeStats(stats)
+				}
+				if p.Stats {
+					c.send(stats)
+				}
 			}
-			turn += 1
-			c.events <- TurnComplete{CompletedTurns: turn} //Report the new state using Event.
 
 			world = newWorld //make newWorld the current world
 			newWorld = nil   //clean up newWorld for next turn
 
 		}
 	}
-	tickerChan.Stop()
 	//Report the final state using FinalTurnCompleteEvent.
-	c.events <- FinalTurnComplete{CompletedTurns: turn, Alive: computeAliveCell(world)}
+	if pl != nil {
+		c.send(FinalTurnComplete{CompletedTurns: turn, Alive: pl.alive()})
+	} else {
+		c.send(FinalTurnComplete{CompletedTurns: turn, Alive: computeAliveCell(world)})
+	}
 
 	//output PGM file
-	currentState(p, world, p.Turns, c)
+	snapshot()
 	// Make sure that the Io has finished any output before exiting.
-	c.ioCommand <- ioCheckIdle
-	<-c.ioIdle
+	c.stopIo()
 
-	c.events <- StateChange{turn, Quitting}
+	c.send(StateChange{turn, Quitting})
 
 	// Close the channel to stop the SDL goroutine gracefully. Removing may cause deadlock.
-	close(c.events)
+	c.closeEvents()
 }
@@ -0,0 +1,121 @@
+package gol
+
+import (
+	"math/rand"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// EditKind selects what an Edit does to the world.
+type EditKind int
+
+const (
+	EditSet       EditKind = iota // make Cell alive or dead
+	EditPaste                     // stamp the cells of Pattern that are not dead with its top-left corner at Cell
+	EditClear                     // kill every cell in the rectangle from Cell to Corner
+	EditRandomise                 // make every cell in the rectangle from Cell to Corner alive with probability Density
+)
+
+// Edit is a change to the world made by the user, e.g. with the mouse in the SDL window.
+// Edits are sent to RunWithEdits and applied by the distributor between turns, so that later turns
+// and the PGM output include them. Cells outside the board wrap around its edges, except on the unbounded
+// plane of Params.Unbounded, where they are just further out.
+type Edit struct {
+	Kind    EditKind
+	Cell    util.Cell
+	Corner  util.Cell
+	Alive   bool      // for EditSet
+	Pattern [][]uint8 // for EditPaste, grey levels of the states of the rule
+	Density float64   // for EditRandomise, 0.5 if 0
+	Seed    int64     // for EditRandomise
+}
+
+// ReadPattern loads a pattern from an RLE (.rle), MCell (.mcl), plaintext (.cells) or PGM (.pgm) file,
+// e.g. for an EditPaste. The states of multi-state patterns are stored as the grey levels of rule.
+func ReadPattern(path string, rule Rule) ([][]uint8, error) {
+	return readPattern(path, rule)
+}
+
+// editable is what an Edit changes: the world, whose edges wrap around, or the unbounded plane.
+type editable interface {
+	// place gives where cell x, y is, wrapping it around the edges if there are any.
+	place(x, y int) (int, int)
+	cell(x, y int) uint8
+	set(x, y int, value uint8)
+}
+
+// torus is the world of a run with edges, made editable.
+type torus [][]uint8
+
+func (t torus) place(x, y int) (int, int) {
+	height, width := len(t), len(t[0])
+	return (x%width + width) % width, (y%height + height) % height
+}
+
+func (t torus) cell(x, y int) uint8 {
+	return t[y][x]
+}
+
+func (t torus) set(x, y int, value uint8) {
+	t[y][x] = value
+}
+
+// apply changes the world and reports every cell that flipped, followed by a WorldEdited event.
+func (e Edit) apply(world editable, turn int, rule Rule, c distributorChannels) {
+	flipped := 0
+	set := func(x, y int, value uint8) {
+		x, y = world.place(x, y)
+		if before := world.cell(x, y); before != value {
+			c.cellChanged(turn, util.Cell{X: x, Y: y}, before, value, rule)
+			world.set(x, y, value)
+			flipped++
+		}
+	}
+	minX, maxX := e.Cell.X, e.Corner.X
+	if minX > maxX {
+		minX, maxX = maxX, minX
+	}
+	minY, maxY := e.Cell.Y, e.Corner.Y
+	if minY > maxY {
+		minY, maxY = maxY, minY
+	}
+
+	switch e.Kind {
+	case EditSet:
+		if e.Alive {
+			set(e.Cell.X, e.Cell.Y, 0xFF)
+		} else {
+			set(e.Cell.X, e.Cell.Y, 0)
+		}
+	case EditPaste:
+		for j, row := range e.Pattern {
+			for i, cell := range row {
+				if cell != 0 {
+					set(e.Cell.X+i, e.Cell.Y+j, rule.normalise(cell))
+				}
+			}
+		}
+	case EditClear:
+		for y := minY; y <= maxY; y++ {
+			for x := minX; x <= maxX; x++ {
+				set(x, y, 0)
+			}
+		}
+	case EditRandomise:
+		density := e.Density
+		if density == 0 {
+			density = 0.5
+		}
+		random := rand.New(rand.NewSource(e.Seed))
+		for y := minY; y <= maxY; y++ {
+			for x := minX; x <= maxX; x++ {
+				if random.Float64() < density {
+					set(x, y, 0xFF)
+				} else {
+					set(x, y, 0)
+				}
+			}
+		}
+	}
+	c.send(WorldEdited{CompletedTurns: turn, Flipped: flipped})
+}
@@ -2,6 +2,7 @@ package gol
 
 import (
 	"fmt"
+	"time"
 	"uk.ac.bris.cs/gameoflife/util"
 )
 
@@ -52,6 +53,22 @@ type CellFlipped struct { // implements Event
 	Cell           util.Cell
 }
 
+// CellStateChanged is an Event notifying the GUI about the new state of a single cell, for rules with more
+// than two states (see Rule). It is sent every time such a cell changes state, next to CellFlipped,
+// which is only sent when a cell becomes alive (state 1) or stops being alive.
+type CellStateChanged struct { // implements Event
+	CompletedTurns int
+	Cell           util.Cell
+	State          int
+}
+
+// AntsMoved is an Event with where the ants of a turmite are, sent before the first turn and then before
+// every TurnComplete, so that the GUI can draw them.
+type AntsMoved struct { // implements Event
+	CompletedTurns int
+	Ants           []Ant
+}
+
 // TurnComplete is an Event notifying the GUI about turn completion.
 // SDL will render a frame when this event is sent.
 // All CellFlipped events must be sent *before* TurnComplete.
@@ -59,9 +76,41 @@ type TurnComplete struct { // implements Event
 	CompletedTurns int
 }
 
+// ThreadsChanged is an Event notifying the user about the number of workers chosen for a run with
+// Params.Threads 0, sent after the TurnComplete of the turn that settled the choice and again whenever
+// a later round of timing picks a different number.
+type ThreadsChanged struct { // implements Event
+	CompletedTurns int
+	Threads        int
+}
+
+// TurnStats is an Event with statistics about the turn that has just been completed.
+// This Event is only sent when Params.Stats is set, straight after TurnComplete.
+// Min and Max are the corners of the bounding box of the alive cells, ActiveTiles is the number of
+// 16x16 tiles in which at least one cell flipped, and Duration is the time the workers took for the turn.
+type TurnStats struct {
+	CompletedTurns int           `json:"completed_turns"`
+	Population     int           `json:"population"`
+	Births         int           `json:"births"`
+	Deaths         int           `json:"deaths"`
+	Min            util.Cell     `json:"min"`
+	Max            util.Cell     `json:"max"`
+	ActiveTiles    int           `json:"active_tiles"`
+	Duration       time.Duration `json:"duration_ns"`
+}
+
+// WorldEdited is an Event notifying the GUI that an Edit has been applied to the world.
+// The CellFlipped events of the edit are sent before it, so SDL renders a frame when this event is sent.
+type WorldEdited struct { // implements Event
+	CompletedTurns int
+	Flipped        int
+}
+
 // FinalTurnComplete is an Event notifying the testing framework about the new world state after execution finished.
 // The data included with this Event is used directly by the tests.
 // SDL closes the window when this Event is sent.
+// Pressing q ends the run early the same way: this Event is sent for the turn reached, and the PGM file output
+// at the end is named after that turn instead of Params.Turns.
 type FinalTurnComplete struct {
 	CompletedTurns int
 	Alive          []util.Cell
@@ -114,6 +163,22 @@ func (event CellFlipped) GetCompletedTurns() int {
 	return event.CompletedTurns
 }
 
+func (event CellStateChanged) String() string {
+	return fmt.Sprintf("")
+}
+
+func (event CellStateChanged) GetCompletedTurns() int {
+	return event.CompletedTurns
+}
+
+func (event AntsMoved) String() string {
+	return fmt.Sprintf("")
+}
+
+func (event AntsMoved) GetCompletedTurns() int {
+	return event.CompletedTurns
+}
+
 func (event TurnComplete) String() string {
 	return fmt.Sprintf("")
 }
@@ -122,6 +187,30 @@ func (event TurnComplete) GetCompletedTurns() int {
 	return event.CompletedTurns
 }
 
+func (event ThreadsChanged) String() string {
+	return fmt.Sprintf("Using %v threads", event.Threads)
+}
+
+func (event ThreadsChanged) GetCompletedTurns() int {
+	return event.CompletedTurns
+}
+
+func (event TurnStats) String() string {
+	return fmt.Sprintf("")
+}
+
+func (event TurnStats) GetCompletedTurns() int {
+	return event.CompletedTurns
+}
+
+func (event WorldEdited) String() string {
+	return fmt.Sprintf("")
+}
+
+func (event WorldEdited) GetCompletedTurns() int {
+	return event.CompletedTurns
+}
+
 func (event FinalTurnComplete) String() string {
 	return fmt.Sprintf("")
 }
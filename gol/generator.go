@@ -0,0 +1,389 @@
+package gol
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Generator describes an initial world that is made up by the io goroutine instead of being read from images/.
+// Kind selects how the world is made:
+//
+//	random  	every cell of the board is alive with probability Density
+//	box     	a Size x Size random square in the centre of an empty board
+//	C2, C4, D8	a random square like box with 2-fold rotational, 4-fold rotational or full square symmetry
+//	pattern 	the RLE, MCell (.mcl), plaintext (.cells) or PGM file Pattern stamped with its top-left corner at X, Y
+//
+// An empty Kind means the world is read from images/ as usual.
+type Generator struct {
+	Kind    string
+	Seed    int64
+	Density float64
+	Size    int    // side of the random square, the shorter side of the board if 0
+	Pattern string // path to the pattern file
+	X, Y    int    // where the pattern is stamped, a negative value centres it along that axis
+}
+
+// world makes the board described by the generator. The states of multi-state patterns are stored as the
+// grey levels of rule.
+func (g Generator) world(width, height int, rule Rule) ([][]uint8, error) {
+	size := g.Size
+	if size <= 0 {
+		size = width
+		if height < size {
+			size = height
+		}
+	}
+	if g.Kind != "random" && g.Kind != "pattern" && (size > width || size > height) {
+		return nil, fmt.Errorf("a %dx%d soup does not fit in a %dx%d board", size, size, width, height)
+	}
+	switch g.Kind {
+	case "random":
+		world := emptyWorld(width, height)
+		fillRandom(world, 0, 0, width, height, g.Density, g.Seed, nil)
+		return world, nil
+	case "box":
+		world := emptyWorld(width, height)
+		fillRandom(world, (width-size)/2, (height-size)/2, size, size, g.Density, g.Seed, nil)
+		return world, nil
+	case "C2", "C4", "D8":
+		world := emptyWorld(width, height)
+		fillRandom(world, (width-size)/2, (height-size)/2, size, size, g.Density, g.Seed, symmetries[g.Kind])
+		return world, nil
+	case "pattern":
+		pattern, err := readPattern(g.Pattern, rule)
+		if err != nil {
+			return nil, err
+		}
+		x, y := g.X, g.Y
+		if x < 0 {
+			x = (width - len(pattern[0])) / 2
+		}
+		if y < 0 {
+			y = (height - len(pattern)) / 2
+		}
+		world := emptyWorld(width, height)
+		stamp(world, pattern, x, y)
+		return world, nil
+	default:
+		return nil, fmt.Errorf("unknown generator %q, should be random, box, C2, C4, D8 or pattern", g.Kind)
+	}
+}
+
+// symmetry maps a cell of a size x size square onto the cells that must have the same value.
+type symmetry func(x, y, size int) [][2]int
+
+var symmetries = map[string]symmetry{
+	"C2": func(x, y, size int) [][2]int {
+		return [][2]int{{size - 1 - x, size - 1 - y}}
+	},
+	"C4": func(x, y, size int) [][2]int {
+		return [][2]int{{size - 1 - y, x}, {size - 1 - x, size - 1 - y}, {y, size - 1 - x}}
+	},
+	"D8": func(x, y, size int) [][2]int {
+		return [][2]int{
+			{size - 1 - y, x}, {size - 1 - x, size - 1 - y}, {y, size - 1 - x},
+			{size - 1 - x, y}, {x, size - 1 - y}, {y, x}, {size - 1 - y, size - 1 - x},
+		}
+	},
+}
+
+// fillRandom fills the w x h rectangle at startX, startY with random cells, drawing one random number per cell.
+// With a symmetry, a cell that is the image of a cell filled earlier copies it instead of drawing a number.
+func fillRandom(world [][]uint8, startX, startY, w, h int, density float64, seed int64, images symmetry) {
+	random := rand.New(rand.NewSource(seed))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			copied := false
+			if images != nil {
+				for _, image := range images(x, y, w) {
+					if image[1] < y || (image[1] == y && image[0] < x) {
+						world[startY+y][startX+x] = world[startY+image[1]][startX+image[0]]
+						copied = true
+						break
+					}
+				}
+			}
+			if !copied && random.Float64() < density {
+				world[startY+y][startX+x] = 0xFF
+			}
+		}
+	}
+}
+
+// stamp copies the alive cells of a pattern into the world with its top-left corner at x, y,
+// wrapping around the edges of the board.
+func stamp(world [][]uint8, pattern [][]uint8, x, y int) {
+	height, width := len(world), len(world[0])
+	for j, row := range pattern {
+		for i, cell := range row {
+			if cell != 0 {
+				world[((y+j)%height+height)%height][((x+i)%width+width)%width] = cell
+			}
+		}
+	}
+}
+
+// readPattern loads a pattern file. The format is chosen from the extension: .rle, .mcl, .cells or .pgm.
+// The states of multi-state RLE and MCell patterns are stored as the grey levels of rule.
+func readPattern(path string, rule Rule) ([][]uint8, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var pattern [][]uint8
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".rle":
+		pattern, err = parseRLE(string(data), rule)
+	case ".mcl":
+		pattern, err = parseMCell(string(data), rule)
+	case ".cells":
+		pattern, err = parsePlaintext(string(data))
+	case ".pgm":
+		pattern, err = parsePGM(data)
+	default:
+		err = fmt.Errorf("%s: unknown pattern format, should be .rle, .mcl, .cells or .pgm", path)
+	}
+	if err == nil && (len(pattern) == 0 || len(pattern[0]) == 0) {
+		err = fmt.Errorf("%s: pattern is empty", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return pattern, nil
+}
+
+// parseRLE reads a pattern in run length encoded format, e.g.
+//
+//	#N Glider
+//	x = 3, y = 3, rule = B3/S23
+//	bob$2bo$3o!
+//
+// Multi-state patterns write state 0 as '.', states 1 to 24 as 'A' to 'X' and higher states with a prefix
+// from 'p' to 'y' that adds 24 to 240, e.g. "pA" for state 25.
+func parseRLE(data string, rule Rule) ([][]uint8, error) {
+	var rows [][]uint8
+	var row []uint8
+	width, run, prefix := 0, 0, 0
+	header := false
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !header && strings.HasPrefix(line, "x") {
+			//the header gives the size of the pattern, which may be larger than its alive cells
+			header = true
+			for _, field := range strings.Split(line, ",") {
+				keyValue := strings.SplitN(field, "=", 2)
+				if len(keyValue) == 2 && strings.TrimSpace(keyValue[0]) == "x" {
+					width, _ = strconv.Atoi(strings.TrimSpace(keyValue[1]))
+				}
+			}
+			continue
+		}
+		for _, symbol := range line {
+			switch {
+			case symbol >= '0' && symbol <= '9':
+				run = run*10 + int(symbol-'0')
+				continue
+			case symbol == 'b' || symbol == '.':
+				row = appendRun(row, 0, run)
+			case symbol == 'o':
+				row = appendRun(row, 0xFF, run)
+			case symbol >= 'p' && symbol <= 'y':
+				prefix = 24 * int(symbol-'p'+1)
+				continue
+			case symbol >= 'A' && symbol <= 'X':
+				value, err := stateValue(prefix+int(symbol-'A'+1), rule)
+				if err != nil {
+					return nil, err
+				}
+				row = appendRun(row, value, run)
+				prefix = 0
+			case symbol == '$':
+				rows = append(rows, row)
+				for i := 1; i < run; i++ {
+					rows = append(rows, nil)
+				}
+				row = nil
+			case symbol == '!':
+				rows = append(rows, row)
+				return padPattern(rows, width), nil
+			default:
+				return nil, fmt.Errorf("unexpected %q in RLE", symbol)
+			}
+			run = 0
+		}
+	}
+	return nil, fmt.Errorf("RLE is missing its closing '!'")
+}
+
+// parseMCell reads a pattern in the MCell format, where the lines starting with #L hold the cells in the
+// multi-state form of RLE, e.g.
+//
+//	#MCell 4.20
+//	#GAME Wireworld
+//	#L .A3C$B
+//
+// but with '.' for state 0, 'A' to 'X' for states 1 to 24 and a prefix from 'a' to 'j' that adds 24 to 240.
+// The other lines describe the game, the board and the colours and are ignored.
+func parseMCell(data string, rule Rule) ([][]uint8, error) {
+	var rows [][]uint8
+	var row []uint8
+	run, prefix := 0, 0
+	found := false
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "#L") {
+			continue
+		}
+		found = true
+		for _, symbol := range strings.TrimSpace(line[2:]) {
+			switch {
+			case symbol >= '0' && symbol <= '9':
+				run = run*10 + int(symbol-'0')
+				continue
+			case symbol >= 'a' && symbol <= 'j':
+				prefix = 24 * int(symbol-'a'+1)
+				continue
+			case symbol == '.':
+				row = appendRun(row, 0, run)
+			case symbol >= 'A' && symbol <= 'X':
+				value, err := stateValue(prefix+int(symbol-'A'+1), rule)
+				if err != nil {
+					return nil, err
+				}
+				row = appendRun(row, value, run)
+				prefix = 0
+			case symbol == '$':
+				rows = append(rows, row)
+				for i := 1; i < run; i++ {
+					rows = append(rows, nil)
+				}
+				row = nil
+			case symbol == ' ':
+				continue
+			default:
+				return nil, fmt.Errorf("unexpected %q in MCell pattern", symbol)
+			}
+			run = 0
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("MCell pattern has no #L lines")
+	}
+	rows = append(rows, row)
+	//drop trailing empty rows
+	for len(rows) > 0 && len(rows[len(rows)-1]) == 0 {
+		rows = rows[:len(rows)-1]
+	}
+	return padPattern(rows, 0), nil
+}
+
+// stateValue gives the grey level of a state of a multi-state pattern under rule.
+func stateValue(state int, rule Rule) (uint8, error) {
+	if state >= rule.states() {
+		return 0, fmt.Errorf("state %d is not a state of rule %v", state, rule)
+	}
+	return rule.Value(state), nil
+}
+
+func appendRun(row []uint8, value uint8, run int) []uint8 {
+	if run == 0 {
+		run = 1
+	}
+	for i := 0; i < run; i++ {
+		row = append(row, value)
+	}
+	return row
+}
+
+// parsePlaintext reads a pattern in plaintext format, where '!' starts a comment, '.' is dead and 'O' is alive.
+func parsePlaintext(data string) ([][]uint8, error) {
+	var rows [][]uint8
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.HasPrefix(line, "!") {
+			continue
+		}
+		var row []uint8
+		for _, symbol := range line {
+			switch symbol {
+			case '.':
+				row = append(row, 0)
+			case 'O', 'o', '*':
+				row = append(row, 0xFF)
+			default:
+				return nil, fmt.Errorf("unexpected %q in plaintext pattern", symbol)
+			}
+		}
+		rows = append(rows, row)
+	}
+	//drop trailing empty lines
+	for len(rows) > 0 && len(rows[len(rows)-1]) == 0 {
+		rows = rows[:len(rows)-1]
+	}
+	return padPattern(rows, 0), nil
+}
+
+// parsePGM reads a pattern from a binary PGM image like the ones in images/. The header may hold comments,
+// and the pixels are taken as raw bytes after it, as their grey levels can be anything, whitespace included.
+func parsePGM(data []byte) ([][]uint8, error) {
+	//the header is the magic number, width, height and maximum grey level, each after whitespace and comments
+	var header [4]string
+	offset := 0
+	for i := range header {
+		for offset < len(data) && (isSpace(data[offset]) || data[offset] == '#') {
+			if data[offset] == '#' {
+				for offset < len(data) && data[offset] != '\n' && data[offset] != '\r' {
+					offset++
+				}
+				continue
+			}
+			offset++
+		}
+		start := offset
+		for offset < len(data) && !isSpace(data[offset]) && data[offset] != '#' {
+			offset++
+		}
+		header[i] = string(data[start:offset])
+	}
+	if header[0] != "P5" {
+		return nil, fmt.Errorf("not a pgm file")
+	}
+	//a single whitespace character separates the header from the pixels
+	offset++
+	width, _ := strconv.Atoi(header[1])
+	height, _ := strconv.Atoi(header[2])
+	if width <= 0 || height <= 0 || offset+width*height > len(data) {
+		return nil, fmt.Errorf("pgm image is smaller than its header says")
+	}
+	image := data[offset : offset+width*height]
+	pattern := emptyWorld(width, height)
+	for y := range pattern {
+		copy(pattern[y], image[y*width:(y+1)*width])
+	}
+	return pattern, nil
+}
+
+// isSpace tells whether a byte is whitespace in the header of a PGM image.
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\v' || b == '\f' || b == '\r'
+}
+
+// padPattern makes every row of a pattern as long as the longest row, or width if that is longer.
+func padPattern(rows [][]uint8, width int) [][]uint8 {
+	for _, row := range rows {
+		if len(row) > width {
+			width = len(row)
+		}
+	}
+	for y, row := range rows {
+		rows[y] = append(row, make([]uint8, width-len(row))...)
+	}
+	return rows
+}
@@ -1,40 +1,141 @@
 package gol
 
+import (
+	"fmt"
+	"path/filepath"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
 // Params provides the details of how to run the Game of Life and which image to load.
 type Params struct {
 	Turns       int
-	Threads     int
+	Threads     int // 0 picks the fastest number of workers while running, see ThreadsChanged
 	ImageWidth  int
 	ImageHeight int
+	Rule        string    // rulestring in B/S notation, Conway's Game of Life if empty
+	Generator   Generator // makes up the initial world instead of reading images/ if its Kind is set
+	Stats       bool      // send a TurnStats event after every turn
+	StatsFile   string    // write the TurnStats of every turn to this .csv or .jsonl file
+	Noise       Noise     // makes the rule stochastic, with reproducible random numbers
+	Turmite     string    // runs ants with this turmite, see ParseTurmite, instead of the rule
+	Ants        []Ant     // the ants of the turmite, one in the centre of the board if empty
+	Reverse     bool      // runs a reversible Margolus rule backwards, taking the loaded world to be Turns turns on
+	// FullRecompute computes every cell in every turn, instead of only the tiles around the last changes
+	FullRecompute bool
+	// TileWidth and TileHeight split the world into tiles that the workers take from a queue, instead of one
+	// strip of rows per worker. A side of 0 spans the whole world; both 0 means strips.
+	TileWidth, TileHeight int
+	// Lookup steps a rule of two states 2x2 cells at a time, with a table of the next states of every 4x4 block
+	Lookup bool
+	// Engine is "channels", the default, or "shared" for goroutines that communicate through memory guarded
+	// by mutexes and condition variables instead of channels, see sharedState
+	Engine string
+	// Unbounded runs on a plane without edges that grows as the cells reach further, see plane, instead of a
+	// board that wraps around. The loaded world starts at 0, 0 and cells can go anywhere, negative
+	// coordinates included, and the PGM output is the smallest rectangle around the cells.
+	Unbounded bool
+	// OutDir is the directory the PGM files are output to, out if empty
+	OutDir string
+}
+
+// ImagePath gives the path of the PGM file output as filename, the Filename of an ImageOutputComplete.
+func (p Params) ImagePath(filename string) string {
+	dir := p.OutDir
+	if dir == "" {
+		dir = "out"
+	}
+	return filepath.Join(dir, filename+".pgm")
+}
+
+// Validate gives the error that Run would panic with for p because of its rule, turmite or board, or nil if
+// p can run, e.g. to check the params a user gave before running them.
+func Validate(p Params) error {
+	rule, err := ParseRule(p.Rule)
+	if err != nil {
+		return err
+	}
+	if p.Lookup {
+		if _, err := lookupTable(rule); err != nil {
+			return err
+		}
+	}
+	if p.Turmite != "" {
+		turmite, err := ParseTurmite(p.Turmite)
+		if err != nil {
+			return err
+		}
+		rule = turmite.colourRule()
+		if _, err := startAnts(p, turmite); err != nil {
+			return err
+		}
+	}
+	if rule.margolus() && p.Reverse {
+		if _, err := rule.inverse(); err != nil {
+			return err
+		}
+	}
+	return checkBoard(p, rule)
 }
 
 // Run starts the processing of Game of Life. It should initialise channels and goroutines.
 func Run(p Params, events chan<- Event, keyPresses <-chan rune) {
+	RunWithEdits(p, events, keyPresses, nil)
+}
+
+// RunWithEdits is Run with a channel of Edits that change the world between turns, e.g. while paused.
+func RunWithEdits(p Params, events chan<- Event, keyPresses <-chan rune, edits <-chan Edit) {
+	bus := NewBus()
+	if events != nil {
+		bus.attach(events)
+	}
+	RunWithBus(p, bus, keyPresses, edits)
+}
+
+// RunWithBus is RunWithEdits with the events published to the subscribers of bus instead of a channel.
+// Events that no subscriber takes are not made at all, see Bus, and bus is closed at the end of the run.
+func RunWithBus(p Params, bus *Bus, keyPresses <-chan rune, edits <-chan Edit) {
+
+	switch p.Engine {
+	case "shared":
+		runShared(p, bus, keyPresses, edits)
+		return
+	case "", "channels":
+	default:
+		util.Check(fmt.Errorf("unknown engine %q, should be channels or shared", p.Engine))
+	}
 
 	//	TODO: Put the missing channels in here.
 	ioCom := make(chan ioCommand)
 	ioIdle := make(chan bool)
 	ioFilename := make(chan string)
+	ioSize := make(chan int)
 	ioIn := make(chan uint8)
 	ioOut := make(chan uint8)
+	ioStats := make(chan TurnStats)
 
 	ioChannels := ioChannels{
 		command:  ioCom,
 		idle:     ioIdle,
 		filename: ioFilename,
+		size:     ioSize,
 		output:   ioOut,
 		input:    ioIn,
+		stats:    ioStats,
 	}
 	go startIo(p, ioChannels)
 
 	distributorChannels := distributorChannels{
-		events:       events,
+		events:       bus,
 		ioCommand:    ioCom,
 		ioIdle:       ioIdle,
 		ioFilename:   ioFilename,
+		ioSize:       ioSize,
 		ioOutput:     ioOut,
 		ioInput:      ioIn,
+		ioStats:      ioStats,
 		ioKeyPresses: keyPresses,
+		edits:        edits,
 	}
 	distributor(p, distributorChannels)
 
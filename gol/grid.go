@@ -0,0 +1,60 @@
+package gol
+
+// Besides the square grid, where every cell has the 8 Moore neighbours, range 1 rules can run on a
+// von Neumann grid, where only the 4 cells along the axes are neighbours, e.g. "B2/S013V", or on a
+// hexagonal grid with 6 neighbours, e.g. "B2/S34H".
+//
+// Hexagonal boards are stored as offset rows in the same 2D world: odd rows are shifted half a cell to the
+// right, so the neighbours of a cell are the cells left and right of it and the two cells touching it in the
+// rows above and below. The rows only keep alternating where the board wraps around if its height is even.
+
+// size gives the number of neighbours of a cell in a range 1 neighbourhood.
+func (n Neighbourhood) size() int {
+	switch n {
+	case VonNeumann:
+		return 4
+	case Hexagonal:
+		return 6
+	}
+	return 8
+}
+
+// offsets gives where the neighbours of a cell on row y are, relative to the cell, in a range 1 neighbourhood
+// other than Moore.
+func (n Neighbourhood) offsets(y int) [][2]int {
+	if n == VonNeumann {
+		return [][2]int{{0, -1}, {-1, 0}, {1, 0}, {0, 1}}
+	}
+	//the rows above and below an even row reach half a cell further left, those of an odd row further right
+	if y%2 == 0 {
+		return [][2]int{{-1, -1}, {0, -1}, {-1, 0}, {1, 0}, {-1, 1}, {0, 1}}
+	}
+	return [][2]int{{0, -1}, {1, -1}, {-1, 0}, {1, 0}, {0, 1}, {1, 1}}
+}
+
+// countGrid counts the alive neighbours of every cell of a strip starting at row startY of the world,
+// on a von Neumann or hexagonal grid.
+func countGrid(topEdge []uint8, botEdge []uint8, world [][]uint8, startY int, neighbourhood Neighbourhood) [][]int {
+	width := len(topEdge)
+	neighbours := make([][]int, len(world))
+	for y := range world {
+		neighbours[y] = make([]int, width)
+		for _, offset := range neighbourhood.offsets(startY + y) {
+			var row []uint8
+			switch y + offset[1] {
+			case -1:
+				row = topEdge
+			case len(world):
+				row = botEdge
+			default:
+				row = world[y+offset[1]]
+			}
+			for x := 0; x < width; x++ {
+				if row[(x+offset[0]+width)%width] == 0xFF {
+					neighbours[y][x]++
+				}
+			}
+		}
+	}
+	return neighbours
+}
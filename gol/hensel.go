@@ -0,0 +1,202 @@
+package gol
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Isotropic non-totalistic rules, in Hensel notation, split every neighbour count from 1 to 7 into classes
+// of configurations that are the same up to rotation and reflection, each named by a letter, e.g. "B2-a/S12"
+// is born with two neighbours unless they are next to each other. There are 51 classes in all.
+//
+// A configuration of the 3x3 block around a cell is numbered with one bit per cell, row by row from the
+// top-left corner, so the cell itself is bit 4 (16).
+
+const centre = 1 << 4
+
+// henselLetters gives the letters of the classes of every neighbour count, in the order they are written.
+var henselLetters = [9]string{
+	0: "",
+	1: "ce",
+	2: "ceaikn",
+	3: "ceaiknjqry",
+	4: "ceaiknjqrytwz",
+	5: "ceaiknjqry",
+	6: "ceaikn",
+	7: "ce",
+	8: "",
+}
+
+// henselClasses gives one configuration of every class of the neighbour counts 1 to 4, in the same order as
+// henselLetters. The classes of 5 to 7 neighbours are the complements of those of 3 to 1 neighbours.
+var henselClasses = [5][]int{
+	1: {1, 2},
+	2: {5, 10, 3, 40, 33, 68},
+	3: {69, 42, 11, 7, 98, 13, 14, 70, 41, 97},
+	4: {325, 170, 15, 45, 99, 71, 106, 102, 43, 101, 105, 78, 108},
+}
+
+// henselClass gives one configuration, without the cell itself, of class letter of count neighbours.
+func henselClass(count int, letter rune) (int, bool) {
+	i := strings.IndexRune(henselLetters[count], letter)
+	if i < 0 {
+		return 0, false
+	}
+	if count > 4 {
+		return henselClasses[8-count][i] ^ 0x1FF ^ centre, true
+	}
+	return henselClasses[count][i], true
+}
+
+// symmetricConfigurations gives a configuration turned and reflected in the 8 ways that keep the 3x3 block in place.
+func symmetricConfigurations(configuration int) [8]int {
+	var images [8]int
+	for bit := 0; bit < 9; bit++ {
+		if configuration&(1<<uint(bit)) == 0 {
+			continue
+		}
+		x, y := bit%3-1, bit/3-1
+		for i, image := range [8][2]int{
+			{x, y}, {-y, x}, {-x, -y}, {y, -x}, {-x, y}, {x, -y}, {y, x}, {-y, -x},
+		} {
+			images[i] |= 1 << uint((image[1]+1)*3+image[0]+1)
+		}
+	}
+	return images
+}
+
+// setClass sets the entries of the table for every configuration of a class, with and without the cell alive.
+func setClass(table []bool, configuration int, alive bool, value bool) {
+	for _, image := range symmetricConfigurations(configuration) {
+		if alive {
+			table[image|centre] = value
+		} else {
+			table[image] = value
+		}
+	}
+}
+
+// parseHensel reads the neighbour counts and letters after the B or S of a rulestring into the table,
+// e.g. "2-a" or "2ck3". alive selects the survival half of the table.
+func parseHensel(table []bool, counts string, alive bool) error {
+	for i := 0; i < len(counts); {
+		digit := counts[i]
+		if digit < '0' || digit > '8' {
+			return fmt.Errorf("%q is not a neighbour count", digit)
+		}
+		count := int(digit - '0')
+		i++
+		exclude := i < len(counts) && counts[i] == '-'
+		if exclude {
+			i++
+		}
+		letters := ""
+		for i < len(counts) && counts[i] >= 'a' && counts[i] <= 'z' {
+			letters += string(counts[i])
+			i++
+		}
+		if exclude && letters == "" {
+			return fmt.Errorf("'-' after %c should be followed by letters", digit)
+		}
+		if count == 0 || count == 8 {
+			if letters != "" {
+				return fmt.Errorf("%c neighbours have no letters", digit)
+			}
+			configuration := 0
+			if count == 8 {
+				configuration = 0x1FF ^ centre
+			}
+			setClass(table, configuration, alive, true)
+			continue
+		}
+		//a count on its own or followed by '-' starts with every class, the letters then add or remove classes
+		if letters == "" || exclude {
+			for _, letter := range henselLetters[count] {
+				configuration, _ := henselClass(count, letter)
+				setClass(table, configuration, alive, true)
+			}
+		}
+		for _, letter := range letters {
+			configuration, ok := henselClass(count, letter)
+			if !ok {
+				return fmt.Errorf("%c neighbours have no class %q", digit, letter)
+			}
+			setClass(table, configuration, alive, !exclude)
+		}
+	}
+	return nil
+}
+
+// henselString writes one half of the table back in Hensel notation, the shorter of the letters
+// that are in the rule or, after a '-', those that are not.
+func henselString(table []bool, alive bool) string {
+	var b strings.Builder
+	for count := 0; count <= 8; count++ {
+		var in, out string
+		if count == 0 || count == 8 {
+			configuration := 0
+			if count == 8 {
+				configuration = 0x1FF ^ centre
+			}
+			if alive {
+				configuration |= centre
+			}
+			if table[configuration] {
+				b.WriteByte(byte('0' + count))
+			}
+			continue
+		}
+		for _, letter := range henselLetters[count] {
+			configuration, _ := henselClass(count, letter)
+			if alive {
+				configuration |= centre
+			}
+			if table[configuration] {
+				in += string(letter)
+			} else {
+				out += string(letter)
+			}
+		}
+		switch {
+		case out == "":
+			b.WriteByte(byte('0' + count))
+		case in == "":
+		case len(out) < len(in):
+			b.WriteString(string(rune('0'+count)) + "-" + out)
+		default:
+			b.WriteString(string(rune('0'+count)) + in)
+		}
+	}
+	return b.String()
+}
+
+// configurations gives the configuration of the 3x3 block around every cell of a strip,
+// with alive cells (0xFF) as set bits, wrapping around the left and right edges.
+func configurations(topEdge []uint8, botEdge []uint8, world [][]uint8) [][]int {
+	width := len(topEdge)
+	//bits gives the three cells of a row around x, the leftmost as the lowest bit
+	bits := func(row []uint8, x int) int {
+		b := 0
+		for i := 0; i < 3; i++ {
+			if row[(x+i-1+width)%width] == 0xFF {
+				b |= 1 << uint(i)
+			}
+		}
+		return b
+	}
+	result := make([][]int, len(world))
+	for y := range world {
+		above, below := topEdge, botEdge
+		if y > 0 {
+			above = world[y-1]
+		}
+		if y < len(world)-1 {
+			below = world[y+1]
+		}
+		result[y] = make([]int, width)
+		for x := 0; x < width; x++ {
+			result[y][x] = bits(above, x) | bits(world[y], x)<<3 | bits(below, x)<<6
+		}
+	}
+	return result
+}
@@ -1,9 +1,12 @@
 package gol
 
 import (
+	"bufio"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"uk.ac.bris.cs/gameoflife/util"
@@ -13,14 +16,19 @@ type ioChannels struct {
 	command  <-chan ioCommand
 	idle     chan<- bool
 	filename <-chan string
+	size     <-chan int
 	output   <-chan uint8
 	input    chan<- uint8
+	stats    <-chan TurnStats
 }
 
 // ioState is the internal ioState of the io goroutine.
 type ioState struct {
 	params   Params
 	channels ioChannels
+	// statsFile is opened when the first TurnStats arrives and stays open until ioQuit at the end of the run.
+	statsFile   *os.File
+	statsWriter *bufio.Writer
 }
 
 // ioCommand allows requesting behaviour from the io (pgm) goroutine.
@@ -32,39 +40,30 @@ type ioCommand uint8
 //	ioOutput 	= 0
 //	ioInput 	= 1
 //	ioCheckIdle = 2
+//	ioStats 	= 3
+//	ioQuit 		= 4
 const (
 	ioOutput ioCommand = iota
 	ioInput
 	ioCheckIdle
+	ioStats
+	ioQuit
 )
 
 // writePgmImage receives an array of bytes and writes it to a pgm file.
 func (io *ioState) writePgmImage() {
-	_ = os.Mkdir("out", os.ModePerm)
-
 	// Request a filename from the distributor.
 	filename := <-io.channels.filename
 
-	file, ioError := os.Create("out/" + filename + ".pgm")
-	util.Check(ioError)
-	defer file.Close()
-
-	_, _ = file.WriteString("P5\n")
-	//_, _ = file.WriteString("# PGM file writer by pnmmodules (https://github.com/owainkenwayucl/pnmmodules).\n")
-	_, _ = file.WriteString(strconv.Itoa(io.params.ImageWidth))
-	_, _ = file.WriteString(" ")
-	_, _ = file.WriteString(strconv.Itoa(io.params.ImageHeight))
-	_, _ = file.WriteString("\n")
-	_, _ = file.WriteString(strconv.Itoa(255))
-	_, _ = file.WriteString("\n")
-
-	world := make([][]byte, io.params.ImageHeight)
+	// The distributor sends the size, which is not the size of the board on the unbounded plane.
+	width, height := <-io.channels.size, <-io.channels.size
+	world := make([][]byte, height)
 	for i := range world {
-		world[i] = make([]byte, io.params.ImageWidth)
+		world[i] = make([]byte, width)
 	}
 
-	for y := 0; y < io.params.ImageHeight; y++ {
-		for x := 0; x < io.params.ImageWidth; x++ {
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
 			val := <-io.channels.output
 			//if val != 0 {
 			//	fmt.Println(x, y)
@@ -73,8 +72,29 @@ func (io *ioState) writePgmImage() {
 		}
 	}
 
-	for y := 0; y < io.params.ImageHeight; y++ {
-		for x := 0; x < io.params.ImageWidth; x++ {
+	io.writeImage(filename, world)
+}
+
+// writeImage writes the world to filename.pgm in Params.OutDir, see Params.ImagePath.
+func (io *ioState) writeImage(filename string, world [][]byte) {
+	path := io.params.ImagePath(filename)
+	_ = os.MkdirAll(filepath.Dir(path), os.ModePerm)
+
+	file, ioError := os.Create(path)
+	util.Check(ioError)
+	defer file.Close()
+
+	_, _ = file.WriteString("P5\n")
+	//_, _ = file.WriteString("# PGM file writer by pnmmodules (https://github.com/owainkenwayucl/pnmmodules).\n")
+	_, _ = file.WriteString(strconv.Itoa(len(world[0])))
+	_, _ = file.WriteString(" ")
+	_, _ = file.WriteString(strconv.Itoa(len(world)))
+	_, _ = file.WriteString("\n")
+	_, _ = file.WriteString(strconv.Itoa(255))
+	_, _ = file.WriteString("\n")
+
+	for y := range world {
+		for x := range world[y] {
 			_, ioError = file.Write([]byte{world[y][x]})
 			util.Check(ioError)
 		}
@@ -83,15 +103,48 @@ func (io *ioState) writePgmImage() {
 	ioError = file.Sync()
 	util.Check(ioError)
 
+	if rule, err := ParseRule(io.params.Rule); err == nil && rule.Neighbourhood == Hexagonal {
+		writeHexagonalImage(io.params.ImagePath(filename+"_hex"), world)
+	}
+
 	fmt.Println("File", filename, "output done!")
 }
 
+// writeHexagonalImage writes a hexagonal board as it looks, to a PGM file 2*width+1 pixels wide where every cell
+// is two pixels wide and the odd rows are shifted right by one pixel, half a cell.
+// The PGM file of the board itself keeps one pixel per cell, so that it can be read back.
+func writeHexagonalImage(path string, world [][]byte) {
+	file, ioError := os.Create(path)
+	util.Check(ioError)
+	defer file.Close()
+
+	width := 2*len(world[0]) + 1
+	_, _ = file.WriteString(fmt.Sprintf("P5\n%d %d\n255\n", width, len(world)))
+	for y, row := range world {
+		pixels := make([]byte, width)
+		for x, cell := range row {
+			pixels[2*x+y%2] = cell
+			pixels[2*x+y%2+1] = cell
+		}
+		_, ioError = file.Write(pixels)
+		util.Check(ioError)
+	}
+	util.Check(file.Sync())
+}
+
 // readPgmImage opens a pgm file and sends its data as an array of bytes.
 func (io *ioState) readPgmImage() {
 
 	// Request a filename from the distributor.
 	filename := <-io.channels.filename
 
+	for _, b := range io.readImage(filename) {
+		io.channels.input <- b
+	}
+}
+
+// readImage gives the cells of images/filename.pgm, row by row.
+func (io *ioState) readImage(filename string) []byte {
 	data, ioError := ioutil.ReadFile("images/" + filename + ".pgm")
 	util.Check(ioError)
 
@@ -118,11 +171,86 @@ func (io *ioState) readPgmImage() {
 
 	image := []byte(fields[4])
 
-	for _, b := range image {
+	fmt.Println("File", filename, "input done!")
+	return image
+}
+
+// generateWorld makes up the world described by Params.Generator and sends it as an array of bytes,
+// just like readPgmImage.
+func (io *ioState) generateWorld() {
+
+	// The distributor still sends a filename, which is not needed here.
+	<-io.channels.filename
+
+	for _, b := range io.generate() {
 		io.channels.input <- b
 	}
+}
 
-	fmt.Println("File", filename, "input done!")
+// generate gives the cells of the world described by Params.Generator, row by row.
+func (io *ioState) generate() []byte {
+	rule, ioError := ParseRule(io.params.Rule)
+	util.Check(ioError)
+	world, ioError := io.params.Generator.world(io.params.ImageWidth, io.params.ImageHeight, rule)
+	util.Check(ioError)
+
+	var cells []byte
+	for _, row := range world {
+		cells = append(cells, row...)
+	}
+
+	fmt.Println("World", io.params.Generator.Kind, "generated!")
+	return cells
+}
+
+// writeStats receives the TurnStats of one turn and appends them to Params.StatsFile.
+func (io *ioState) writeStats() {
+	io.appendStats(<-io.channels.stats)
+}
+
+// appendStats appends the TurnStats of one turn to Params.StatsFile.
+// Files ending in .json or .jsonl get one JSON object per line, anything else gets CSV.
+func (io *ioState) appendStats(stats TurnStats) {
+	jsonLines := strings.HasPrefix(filepath.Ext(io.params.StatsFile), ".json")
+	if io.statsFile == nil {
+		if dir := filepath.Dir(io.params.StatsFile); dir != "." {
+			_ = os.MkdirAll(dir, os.ModePerm)
+		}
+		file, ioError := os.Create(io.params.StatsFile)
+		util.Check(ioError)
+		io.statsFile = file
+		io.statsWriter = bufio.NewWriter(file)
+		if !jsonLines {
+			_, _ = io.statsWriter.WriteString("completed_turns,population,births,deaths,min_x,min_y,max_x,max_y,active_tiles,duration_ns\n")
+		}
+	}
+
+	if jsonLines {
+		line, ioError := json.Marshal(stats)
+		util.Check(ioError)
+		_, _ = io.statsWriter.Write(append(line, '\n'))
+	} else {
+		_, _ = fmt.Fprintf(io.statsWriter, "%d,%d,%d,%d,%d,%d,%d,%d,%d,%d\n",
+			stats.CompletedTurns, stats.Population, stats.Births, stats.Deaths,
+			stats.Min.X, stats.Min.Y, stats.Max.X, stats.Max.Y, stats.ActiveTiles, stats.Duration.Nanoseconds())
+	}
+}
+
+// flushStats makes sure every TurnStats received so far is in the stats file.
+func (io *ioState) flushStats() {
+	if io.statsWriter != nil {
+		util.Check(io.statsWriter.Flush())
+		util.Check(io.statsFile.Sync())
+	}
+}
+
+// closeStats writes out every TurnStats received and closes the stats file, once the run has ended.
+func (io *ioState) closeStats() {
+	if io.statsWriter != nil {
+		util.Check(io.statsWriter.Flush())
+		util.Check(io.statsFile.Close())
+		io.statsFile, io.statsWriter = nil, nil
+	}
 }
 
 // startIo should be the entrypoint of the io goroutine.
@@ -138,11 +266,22 @@ func startIo(p Params, c ioChannels) {
 		case command := <-io.channels.command:
 			switch command {
 			case ioInput:
-				io.readPgmImage()
+				if io.params.Generator.Kind != "" {
+					io.generateWorld()
+				} else {
+					io.readPgmImage()
+				}
 			case ioOutput:
 				io.writePgmImage()
 			case ioCheckIdle:
+				io.flushStats()
+				io.channels.idle <- true
+			case ioStats:
+				io.writeStats()
+			case ioQuit:
+				io.closeStats()
 				io.channels.idle <- true
+				return
 			}
 		}
 	}
@@ -0,0 +1,325 @@
+package gol
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// A journal is a recording of the events of a run and of the key presses of its user, which can be played
+// back without running the engine. It starts with journalMagic, a version byte and the Params of the run
+// as JSON after their length, followed by one record per event or key press:
+//
+//	kind byte, microseconds since the last record, turns since the last event, then the fields of the event
+//
+// Every number is a varint, so that the CellFlipped records that make up most of a journal only take a
+// few bytes each. Key presses have no turns, just the key.
+
+// journalMagic starts every journal.
+const journalMagic = "GOLJ"
+
+// journalVersion is the version of the format of the records.
+const journalVersion = 1
+
+// journalKind is the first byte of a record, which says what it holds.
+type journalKind uint8
+
+const (
+	journalKey journalKind = iota
+	journalCellFlipped
+	journalCellStateChanged
+	journalTurnComplete
+	journalAliveCellsCount
+	journalImageOutputComplete
+	journalStateChange
+	journalAntsMoved
+	journalThreadsChanged
+	journalTurnStats
+	journalWorldEdited
+	journalFinalTurnComplete
+)
+
+// JournalEntry is one record of a journal: an event, or a key press if Event is nil.
+type JournalEntry struct {
+	Time  time.Duration // since the journal was started
+	Event Event
+	Key   rune
+}
+
+// JournalWriter records events and key presses to a journal as they happen.
+type JournalWriter struct {
+	w     *bufio.Writer
+	start time.Time
+	last  time.Duration
+	turn  int
+	// record is the record being put together, kept between records so that it is only allocated once.
+	record []byte
+}
+
+// NewJournalWriter starts a journal of a run of p, whose records are timed from now on.
+func NewJournalWriter(w io.Writer, p Params) (*JournalWriter, error) {
+	params, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	j := &JournalWriter{w: bufio.NewWriter(w), start: time.Now()}
+	var length [binary.MaxVarintLen64]byte
+	header := append([]byte(journalMagic), journalVersion)
+	header = append(header, length[:binary.PutUvarint(length[:], uint64(len(params)))]...)
+	if _, err := j.w.Write(append(header, params...)); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// begin starts a record of kind, timed now.
+func (j *JournalWriter) begin(kind journalKind) {
+	now := time.Since(j.start)
+	var micros [binary.MaxVarintLen64]byte
+	j.record = append(j.record[:0], byte(kind))
+	j.record = append(j.record, micros[:binary.PutUvarint(micros[:], uint64((now-j.last)/time.Microsecond))]...)
+	//the time of the record is rounded down, so that the rounding does not add up over the records
+	j.last += (now - j.last) / time.Microsecond * time.Microsecond
+}
+
+func (j *JournalWriter) number(n int) {
+	var buf [binary.MaxVarintLen64]byte
+	j.record = append(j.record, buf[:binary.PutVarint(buf[:], int64(n))]...)
+}
+
+func (j *JournalWriter) cell(cell util.Cell) {
+	j.number(cell.X)
+	j.number(cell.Y)
+}
+
+// Key records a key press.
+func (j *JournalWriter) Key(key rune) error {
+	j.begin(journalKey)
+	j.number(int(key))
+	_, err := j.w.Write(j.record)
+	return err
+}
+
+// Event records an event, or gives an error for an event that is not one of those of this package.
+func (j *JournalWriter) Event(event Event) error {
+	var kind journalKind
+	switch event.(type) {
+	case CellFlipped:
+		kind = journalCellFlipped
+	case CellStateChanged:
+		kind = journalCellStateChanged
+	case TurnComplete:
+		kind = journalTurnComplete
+	case AliveCellsCount:
+		kind = journalAliveCellsCount
+	case ImageOutputComplete:
+		kind = journalImageOutputComplete
+	case StateChange:
+		kind = journalStateChange
+	case AntsMoved:
+		kind = journalAntsMoved
+	case ThreadsChanged:
+		kind = journalThreadsChanged
+	case TurnStats:
+		kind = journalTurnStats
+	case WorldEdited:
+		kind = journalWorldEdited
+	case FinalTurnComplete:
+		kind = journalFinalTurnComplete
+	default:
+		return fmt.Errorf("cannot record event %T in a journal", event)
+	}
+	j.begin(kind)
+	turn := event.GetCompletedTurns()
+	j.number(turn - j.turn)
+	j.turn = turn
+
+	switch e := event.(type) {
+	case CellFlipped:
+		j.cell(e.Cell)
+	case CellStateChanged:
+		j.cell(e.Cell)
+		j.number(e.State)
+	case AliveCellsCount:
+		j.number(e.CellsCount)
+	case ImageOutputComplete:
+		j.number(len(e.Filename))
+		j.record = append(j.record, e.Filename...)
+	case StateChange:
+		j.number(int(e.NewState))
+	case AntsMoved:
+		j.number(len(e.Ants))
+		for _, ant := range e.Ants {
+			j.cell(util.Cell{X: ant.X, Y: ant.Y})
+			j.number(int(ant.Direction))
+			j.number(ant.State)
+		}
+	case ThreadsChanged:
+		j.number(e.Threads)
+	case TurnStats:
+		j.number(e.Population)
+		j.number(e.Births)
+		j.number(e.Deaths)
+		j.cell(e.Min)
+		j.cell(e.Max)
+		j.number(e.ActiveTiles)
+		j.number(int(e.Duration))
+	case WorldEdited:
+		j.number(e.Flipped)
+	case FinalTurnComplete:
+		j.number(len(e.Alive))
+		for _, cell := range e.Alive {
+			j.cell(cell)
+		}
+	}
+	_, err := j.w.Write(j.record)
+	return err
+}
+
+// Flush writes the records that are still buffered, e.g. once the run has ended.
+func (j *JournalWriter) Flush() error {
+	return j.w.Flush()
+}
+
+// JournalReader reads the records of a journal back one at a time.
+type JournalReader struct {
+	// Params are those of the run that was recorded.
+	Params Params
+	r      *bufio.Reader
+	time   time.Duration
+	turn   int
+	err    error
+}
+
+// NewJournalReader reads the start of a journal, up to the first record.
+func NewJournalReader(r io.Reader) (*JournalReader, error) {
+	j := &JournalReader{r: bufio.NewReader(r)}
+	header := make([]byte, len(journalMagic)+1)
+	if _, err := io.ReadFull(j.r, header); err != nil {
+		return nil, err
+	}
+	if string(header[:len(journalMagic)]) != journalMagic {
+		return nil, fmt.Errorf("not a journal")
+	}
+	if header[len(journalMagic)] != journalVersion {
+		return nil, fmt.Errorf("journal version %d, only version %d can be read", header[len(journalMagic)], journalVersion)
+	}
+	length, err := binary.ReadUvarint(j.r)
+	if err != nil {
+		return nil, err
+	}
+	params := make([]byte, length)
+	if _, err := io.ReadFull(j.r, params); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(params, &j.Params); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// number reads a number of a record, keeping the first error so that a record is checked once it has been read.
+func (j *JournalReader) number() int {
+	if j.err != nil {
+		return 0
+	}
+	n, err := binary.ReadVarint(j.r)
+	j.err = err
+	return int(n)
+}
+
+// length reads the length of a list or string of a record, which a corrupt journal could make anything.
+func (j *JournalReader) length() int {
+	n := j.number()
+	if n < 0 || n > 1<<30 {
+		j.err = fmt.Errorf("corrupt journal, length %d", n)
+		return 0
+	}
+	return n
+}
+
+func (j *JournalReader) cell() util.Cell {
+	x := j.number()
+	return util.Cell{X: x, Y: j.number()}
+}
+
+// Next gives the next record, and io.EOF after the last one.
+func (j *JournalReader) Next() (JournalEntry, error) {
+	kind, err := j.r.ReadByte()
+	if err != nil {
+		return JournalEntry{}, err
+	}
+	micros, err := binary.ReadUvarint(j.r)
+	if err != nil {
+		return JournalEntry{}, io.ErrUnexpectedEOF
+	}
+	j.time += time.Duration(micros) * time.Microsecond
+	entry := JournalEntry{Time: j.time}
+	if journalKind(kind) == journalKey {
+		entry.Key = rune(j.number())
+		return entry, j.check()
+	}
+	j.turn += j.number()
+	turn := j.turn
+
+	switch journalKind(kind) {
+	case journalCellFlipped:
+		entry.Event = CellFlipped{CompletedTurns: turn, Cell: j.cell()}
+	case journalCellStateChanged:
+		cell := j.cell()
+		entry.Event = CellStateChanged{CompletedTurns: turn, Cell: cell, State: j.number()}
+	case journalTurnComplete:
+		entry.Event = TurnComplete{CompletedTurns: turn}
+	case journalAliveCellsCount:
+		entry.Event = AliveCellsCount{CompletedTurns: turn, CellsCount: j.number()}
+	case journalImageOutputComplete:
+		filename := make([]byte, j.length())
+		if j.err == nil {
+			_, j.err = io.ReadFull(j.r, filename)
+		}
+		entry.Event = ImageOutputComplete{CompletedTurns: turn, Filename: string(filename)}
+	case journalStateChange:
+		entry.Event = StateChange{CompletedTurns: turn, NewState: State(j.number())}
+	case journalAntsMoved:
+		var ants []Ant
+		for n := j.length(); n > 0 && j.err == nil; n-- {
+			cell := j.cell()
+			ants = append(ants, Ant{X: cell.X, Y: cell.Y, Direction: Direction(j.number()), State: j.number()})
+		}
+		entry.Event = AntsMoved{CompletedTurns: turn, Ants: ants}
+	case journalThreadsChanged:
+		entry.Event = ThreadsChanged{CompletedTurns: turn, Threads: j.number()}
+	case journalTurnStats:
+		stats := TurnStats{CompletedTurns: turn, Population: j.number(), Births: j.number(), Deaths: j.number()}
+		stats.Min, stats.Max = j.cell(), j.cell()
+		stats.ActiveTiles, stats.Duration = j.number(), time.Duration(j.number())
+		entry.Event = stats
+	case journalWorldEdited:
+		entry.Event = WorldEdited{CompletedTurns: turn, Flipped: j.number()}
+	case journalFinalTurnComplete:
+		var alive []util.Cell
+		for n := j.length(); n > 0 && j.err == nil; n-- {
+			alive = append(alive, j.cell())
+		}
+		entry.Event = FinalTurnComplete{CompletedTurns: turn, Alive: alive}
+	default:
+		return JournalEntry{}, fmt.Errorf("unknown record %d in journal", kind)
+	}
+	return entry, j.check()
+}
+
+// check gives the error of the record that has just been read, with the end of the journal in the middle
+// of a record as io.ErrUnexpectedEOF.
+func (j *JournalReader) check() error {
+	err := j.err
+	j.err = nil
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,134 @@
+package gol
+
+import (
+	"fmt"
+	"sync"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// Rules with two states whose cells only depend on their 3x3 block can be stepped 2x2 cells at a time: the
+// next state of the 4 cells in the centre of a 4x4 block only depends on the 16 cells of the block, so a
+// table of 65536 entries gives all of them at once. Bit 4r+c of an entry's index is the cell in row r and
+// column c of the block, and bits 0 to 3 of the entry are the next states of its centre cells in the order
+// top-left, top-right, bottom-left, bottom-right.
+
+// lookupTables keeps the table of every rule that has been used, by rulestring, so that a table is only
+// built the first time its rule is used.
+var lookupTables = struct {
+	sync.Mutex
+	tables map[string][]uint8
+}{tables: make(map[string][]uint8)}
+
+// lookupTable gives the table of a rule, or an error if the rule cannot be stepped with one.
+func lookupTable(rule Rule) ([]uint8, error) {
+	switch {
+	case rule.states() != 2:
+		return nil, fmt.Errorf("rule %v has %d states, a lookup table needs 2", rule, rule.states())
+	case rule.largerThanLife() || rule.margolus() || rule.Neighbourhood == Hexagonal:
+		return nil, fmt.Errorf("rule %v does not only depend on the 3x3 block of a cell, which a lookup table needs", rule)
+	}
+	lookupTables.Lock()
+	defer lookupTables.Unlock()
+	if table, ok := lookupTables.tables[rule.String()]; ok {
+		return table, nil
+	}
+
+	//the next state of the centre of every 3x3 block, found by stepping the block as a 3x3 torus, on which
+	//the 8 other cells are exactly the neighbours of the centre
+	rule.noise, rule.lookup = Noise{}, nil
+	var centre [512]bool
+	for block := range centre {
+		world := emptyWorld(3, 3)
+		for i := 0; i < 9; i++ {
+			if block&(1<<uint(i)) != 0 {
+				world[i/3][i%3] = 0xFF
+			}
+		}
+		centre[block] = nextGeneration(world, rule)[1][1] == 0xFF
+	}
+
+	table := make([]uint8, 1<<16)
+	for index := range table {
+		for i, corner := range [4][2]int{{0, 0}, {0, 1}, {1, 0}, {1, 1}} {
+			block := 0
+			for j := 0; j < 9; j++ {
+				if index&(1<<uint(4*(corner[0]+j/3)+corner[1]+j%3)) != 0 {
+					block |= 1 << uint(j)
+				}
+			}
+			if centre[block] {
+				table[index] |= 1 << uint(i)
+			}
+		}
+	}
+	lookupTables.tables[rule.String()] = table
+	return table, nil
+}
+
+// lookupTurn computes the next state of pieceOfWorld like worldAfterOneTurn, 2x2 cells at a time with the
+// table of the rule. A last row or column left over from odd sides is computed with the row or column after
+// it, which the piece does not keep.
+func lookupTurn(width int, pieceOfWorld [][]uint8, topEdge, botEdge []uint8, startY int, rule Rule, c distributorChannels, turn int) [][]uint8 {
+	height := len(pieceOfWorld)
+	empty := make([]uint8, width)
+	//row gives row h of the piece, the halo just outside it and an empty row further out, which never
+	//changes the cells that are kept
+	row := func(h int) []uint8 {
+		switch {
+		case h < 0:
+			return topEdge
+		case h < height:
+			return pieceOfWorld[h]
+		case h == height:
+			return botEdge
+		}
+		return empty
+	}
+	newWorld := make([][]uint8, height)
+	for h := range newWorld {
+		newWorld[h] = make([]uint8, width)
+	}
+	for h := 0; h < height; h += 2 {
+		rows := [4][]uint8{row(h - 1), row(h), row(h + 1), row(h + 2)}
+		//the block moves 2 columns at a time, so its 2 columns on the left are the 2 on the right of the last
+		//block, and only 2 new columns are read
+		index := 0
+		for r, cells := range rows {
+			if cells[width-1] == 0xFF {
+				index |= 1 << uint(4*r+2)
+			}
+			if cells[0] == 0xFF {
+				index |= 1 << uint(4*r+3)
+			}
+		}
+		for w := 0; w < width; w += 2 {
+			index = (index >> 2) & 0x3333
+			for r, cells := range rows {
+				if cells[(w+1)%width] == 0xFF {
+					index |= 1 << uint(4*r+2)
+				}
+				if cells[(w+2)%width] == 0xFF {
+					index |= 1 << uint(4*r+3)
+				}
+			}
+			next := rule.lookup[index]
+			for i := 0; i < 4; i++ {
+				y, x := h+i/2, w+i%2
+				if y >= height || x >= width {
+					continue
+				}
+				if next&(1<<uint(i)) != 0 {
+					newWorld[y][x] = 0xFF
+				}
+				if rule.noise.active() {
+					newWorld[y][x] = rule.noise.apply(pieceOfWorld[y][x], newWorld[y][x], turn, x, startY+y, rule)
+				}
+				if newWorld[y][x] != pieceOfWorld[y][x] {
+					c.cellChanged(turn, util.Cell{X: x, Y: startY + y}, pieceOfWorld[y][x], newWorld[y][x], rule)
+				}
+			}
+		}
+	}
+	return newWorld
+}
@@ -0,0 +1,241 @@
+package gol
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Neighbourhood is the shape of the cells that count as the neighbours of a cell.
+type Neighbourhood byte
+
+const (
+	Moore      Neighbourhood = 'M' // the (2R+1)x(2R+1) square around the cell
+	VonNeumann Neighbourhood = 'N' // the diamond of cells at most R steps away along the axes
+	Circular   Neighbourhood = 'C' // the cells whose centres are within R+0.5 of the centre of the cell
+	Hexagonal  Neighbourhood = 'H' // the 6 cells around a cell of a hexagonal grid, only for range 1 rules
+)
+
+// maxRange is the largest radius of a Larger than Life neighbourhood.
+const maxRange = 10
+
+// Interval is a range of neighbour counts, Min and Max included.
+type Interval struct {
+	Min, Max int
+}
+
+// parseLargerThanLife reads a Larger than Life rulestring, e.g. "R5,C0,M1,S34..58,B34..45,NM" for Bosco's rule.
+// R is the range, C the number of states (0 or 2 for two states), M1 counts the cell itself as a neighbour,
+// S and B are lists of intervals of neighbour counts written as "34..58", "34-58" or "34", and
+// N is the neighbourhood: M for Moore, N for von Neumann or C for circular.
+func parseLargerThanLife(rulestring string) (Rule, error) {
+	rule := Rule{States: 2, Range: 1, Neighbourhood: Moore}
+	fail := func(format string, a ...interface{}) (Rule, error) {
+		return Rule{States: 2}, fmt.Errorf("rule %q: %s", rulestring, fmt.Sprintf(format, a...))
+	}
+	var intervals *[]Interval
+	for _, part := range strings.Split(strings.ToUpper(strings.TrimSpace(rulestring)), ",") {
+		if part == "" {
+			continue
+		}
+		//a part starting with a digit is one more interval of the previous S or B
+		if part[0] >= '0' && part[0] <= '9' {
+			if intervals == nil {
+				return fail("interval %q does not follow S or B", part)
+			}
+			interval, err := parseInterval(part)
+			if err != nil {
+				return fail("%v", err)
+			}
+			*intervals = append(*intervals, interval)
+			continue
+		}
+		value := part[1:]
+		intervals = nil
+		switch part[0] {
+		case 'R':
+			r, err := strconv.Atoi(value)
+			if err != nil || r < 1 || r > maxRange {
+				return fail("range %q should be between 1 and %d", value, maxRange)
+			}
+			rule.Range = r
+		case 'C':
+			states, err := strconv.Atoi(value)
+			if err != nil || states < 0 || states == 1 || states > maxStates {
+				return fail("%q should give 0 or between 2 and %d states", value, maxStates)
+			}
+			if states > 2 {
+				rule.States = states
+			}
+		case 'M':
+			if value != "0" && value != "1" {
+				return fail("middle %q should be 0 or 1", value)
+			}
+			rule.Middle = value == "1"
+		case 'S', 'B':
+			if part[0] == 'S' {
+				intervals = &rule.SurviveIntervals
+			} else {
+				intervals = &rule.BirthIntervals
+			}
+			if value != "" {
+				interval, err := parseInterval(value)
+				if err != nil {
+					return fail("%v", err)
+				}
+				*intervals = append(*intervals, interval)
+			}
+		case 'N':
+			if len(value) != 1 {
+				return fail("neighbourhood %q should be M, N or C", value)
+			}
+			switch Neighbourhood(value[0]) {
+			case Moore, VonNeumann, Circular:
+				rule.Neighbourhood = Neighbourhood(value[0])
+			default:
+				return fail("neighbourhood %q should be M, N or C", value)
+			}
+		default:
+			return fail("part %q should start with R, C, M, S, B or N", part)
+		}
+	}
+	return rule, nil
+}
+
+func parseInterval(s string) (Interval, error) {
+	bounds := strings.SplitN(strings.Replace(s, "..", "-", 1), "-", 2)
+	min, err := strconv.Atoi(bounds[0])
+	max := min
+	if err == nil && len(bounds) == 2 {
+		max, err = strconv.Atoi(bounds[1])
+	}
+	if err != nil || min < 0 || max < min {
+		return Interval{}, fmt.Errorf("%q is not an interval of neighbour counts", s)
+	}
+	return Interval{min, max}, nil
+}
+
+// largerThanLifeString gives a Larger than Life rule back in the notation read by parseLargerThanLife.
+func (r Rule) largerThanLifeString() string {
+	intervals := func(letter string, list []Interval) string {
+		var parts []string
+		for _, interval := range list {
+			if interval.Min == interval.Max {
+				parts = append(parts, strconv.Itoa(interval.Min))
+			} else {
+				parts = append(parts, fmt.Sprintf("%d..%d", interval.Min, interval.Max))
+			}
+		}
+		return letter + strings.Join(parts, ",")
+	}
+	states, middle := 0, 0
+	if r.states() > 2 {
+		states = r.States
+	}
+	if r.Middle {
+		middle = 1
+	}
+	return fmt.Sprintf("R%d,C%d,M%d,%s,%s,N%c", r.Range, states, middle,
+		intervals("S", r.SurviveIntervals), intervals("B", r.BirthIntervals), r.Neighbourhood)
+}
+
+func inIntervals(n int, intervals []Interval) bool {
+	for _, interval := range intervals {
+		if n >= interval.Min && n <= interval.Max {
+			return true
+		}
+	}
+	return false
+}
+
+// reach gives, for every row offset dy from -R to R, how far the neighbourhood reaches left and right on that row.
+func (r Rule) reach() []int {
+	reach := make([]int, 2*r.Range+1)
+	for dy := -r.Range; dy <= r.Range; dy++ {
+		switch r.Neighbourhood {
+		case VonNeumann:
+			reach[dy+r.Range] = r.Range - abs(dy)
+		case Circular:
+			//dx*dx + dy*dy <= (R+0.5)^2, which for whole numbers is dx*dx + dy*dy <= R*R + R
+			reach[dy+r.Range] = int(math.Sqrt(float64(r.Range*r.Range + r.Range - dy*dy)))
+		default:
+			reach[dy+r.Range] = r.Range
+		}
+	}
+	return reach
+}
+
+// countLargerThanLife counts the alive cells in the neighbourhood of every cell of a strip of the world.
+// rows holds the strip with Range rows of halo above and below it.
+// Moore neighbourhoods are counted with a summed-area table, the others row by row with prefix sums.
+func countLargerThanLife(rows [][]uint8, rule Rule) [][]int {
+	r := rule.Range
+	width := len(rows[0])
+	height := len(rows) - 2*r
+	//prefix[y][x] is the number of alive cells of row y left of column x-R, so that the columns wrap around
+	prefix := make([][]int, len(rows))
+	for y, row := range rows {
+		prefix[y] = make([]int, width+2*r+1)
+		for x := 0; x < width+2*r; x++ {
+			alive := 0
+			if row[(x-r+width)%width] == 0xFF {
+				alive = 1
+			}
+			prefix[y][x+1] = prefix[y][x] + alive
+		}
+	}
+
+	counts := make([][]int, height)
+	if rule.Neighbourhood == Moore {
+		//turn the row prefix sums into a summed-area table of the strip and its halo
+		for y := 1; y < len(prefix); y++ {
+			for x := range prefix[y] {
+				prefix[y][x] += prefix[y-1][x]
+			}
+		}
+		for y := 0; y < height; y++ {
+			counts[y] = make([]int, width)
+			bottom, top := prefix[y+2*r], []int(nil)
+			if y > 0 {
+				top = prefix[y-1]
+			}
+			for x := 0; x < width; x++ {
+				n := bottom[x+2*r+1] - bottom[x]
+				if top != nil {
+					n -= top[x+2*r+1] - top[x]
+				}
+				counts[y][x] = n
+			}
+		}
+	} else {
+		reach := rule.reach()
+		for y := 0; y < height; y++ {
+			counts[y] = make([]int, width)
+			for dy, w := range reach {
+				row := prefix[y+dy]
+				for x := 0; x < width; x++ {
+					counts[y][x] += row[x+r+w+1] - row[x+r-w]
+				}
+			}
+		}
+	}
+
+	if !rule.Middle {
+		for y := 0; y < height; y++ {
+			for x, cell := range rows[y+r] {
+				if cell == 0xFF {
+					counts[y][x]--
+				}
+			}
+		}
+	}
+	return counts
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,122 @@
+package gol
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// Margolus rules are block cellular automata: the board is split into 2x2 blocks and every block is replaced
+// by a new block looked up in a table of 16 entries. The blocks start at even rows and columns on even
+// generations and at odd ones on odd generations, so that the blocks of one generation straddle those of
+// the last. The width and height of the board must be even.
+//
+// A block is numbered with one bit per cell: 1 for the top-left cell, 2 for the top-right, 4 for the
+// bottom-left and 8 for the bottom-right. Rules whose table is a permutation of 0 to 15, like Critters and
+// the billiard-ball machine, are reversible and can run backwards.
+
+// parseMargolus reads a Margolus rule in the notation of Golly, e.g. "MS,D0;8;4;3;2;5;9;7;1;6;10;11;12;13;14;15"
+// for the billiard-ball machine. The "MS,D" may be left out after an M, and commas may separate the entries.
+func parseMargolus(rulestring string) (Rule, error) {
+	rule := Rule{States: 2, Range: 1, Neighbourhood: Moore}
+	body := strings.ToUpper(strings.TrimSpace(rulestring))
+	body = strings.TrimPrefix(strings.TrimPrefix(body, "MS,D"), "M")
+	entries := strings.FieldsFunc(body, func(r rune) bool {
+		return r == ';' || r == ','
+	})
+	if len(entries) != 16 {
+		return rule, fmt.Errorf("rule %q should have 16 entries, one for every 2x2 block", rulestring)
+	}
+	rule.Margolus = make([]int, 16)
+	for i, entry := range entries {
+		block, err := strconv.Atoi(strings.TrimSpace(entry))
+		if err != nil || block < 0 || block > 15 {
+			return rule, fmt.Errorf("rule %q: entry %q should be a block from 0 to 15", rulestring, entry)
+		}
+		rule.Margolus[i] = block
+	}
+	return rule, nil
+}
+
+// margolusString gives a Margolus rule back in the notation of Golly.
+func (r Rule) margolusString() string {
+	entries := make([]string, len(r.Margolus))
+	for i, block := range r.Margolus {
+		entries[i] = strconv.Itoa(block)
+	}
+	return "MS,D" + strings.Join(entries, ";")
+}
+
+// margolus tells whether the rule is a block rule on the Margolus neighbourhood.
+func (r Rule) margolus() bool {
+	return r.Margolus != nil
+}
+
+// inverse gives the table that undoes a Margolus rule, or an error if the rule is not reversible.
+func (r Rule) inverse() ([]int, error) {
+	inverse := make([]int, 16)
+	seen := make([]bool, 16)
+	for block, next := range r.Margolus {
+		if seen[next] {
+			return nil, fmt.Errorf("rule %v is not reversible, more than one block becomes block %d", r, next)
+		}
+		seen[next] = true
+		inverse[next] = block
+	}
+	return inverse, nil
+}
+
+// margolusWorker replaces the blocks of the block rows from first to last, writing the rows they cover into next.
+// Block row b covers rows 2b+phase and 2b+phase+1, wrapping around the bottom of the board.
+func margolusWorker(world, next [][]uint8, first, last, phase int, table []int, turn int, c distributorChannels, done func()) {
+	height, width := len(world), len(world[0])
+	for b := first; b <= last; b++ {
+		top, bottom := 2*b+phase, (2*b+phase+1)%height
+		next[top] = make([]uint8, width)
+		next[bottom] = make([]uint8, width)
+		for x := phase; x < width+phase; x += 2 {
+			left, right := x, (x+1)%width
+			cells := [4]util.Cell{{X: left, Y: top}, {X: right, Y: top}, {X: left, Y: bottom}, {X: right, Y: bottom}}
+			block := 0
+			for i, cell := range cells {
+				if world[cell.Y][cell.X] == 0xFF {
+					block |= 1 << uint(i)
+				}
+			}
+			block = table[block]
+			for i, cell := range cells {
+				if block&(1<<uint(i)) != 0 {
+					next[cell.Y][cell.X] = 0xFF
+				}
+				if next[cell.Y][cell.X] != world[cell.Y][cell.X] {
+					c.cellFlipped(turn, cell)
+				}
+			}
+		}
+	}
+	done()
+}
+
+// margolusTurn applies a block table to the blocks of the given phase, with the block rows split between
+// threads. Splitting whole block rows keeps the blocks of a worker together whichever phase the turn is in.
+func margolusTurn(world [][]uint8, phase int, table []int, threads, turn int, c distributorChannels) [][]uint8 {
+	next := make([][]uint8, len(world))
+	blockRows := len(world) / 2
+	if threads > blockRows {
+		threads = blockRows
+	}
+	done, wait := c.join(threads)
+	first := 0
+	for thread := 0; thread < threads; thread++ {
+		rows := blockRows / threads
+		if thread < blockRows%threads {
+			rows++
+		}
+		go margolusWorker(world, next, first, first+rows-1, phase, table, turn, c, done)
+		first += rows
+	}
+	wait()
+	return next
+}
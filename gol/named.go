@@ -0,0 +1,71 @@
+package gol
+
+import "strings"
+
+// wireworld is the name of Wireworld, a rule for digital circuits with four states:
+//
+//	0	empty, which stays empty
+//	1	electron head, which becomes a tail
+//	2	electron tail, which becomes a conductor
+//	3	conductor, which becomes a head if one or two of its 8 neighbours are heads
+//
+// The states are stored like those of a Generations rule with four states, so heads are 0xFF and count
+// as the alive cells, tails are 170 and conductors 85.
+const wireworld = "Wireworld"
+
+// namedRules gives the rulestrings of well-known rules, so that they can be given by name, e.g. "HighLife".
+var namedRules = map[string]string{
+	"life":             conway,
+	"conway":           conway,
+	"highlife":         "B36/S23",
+	"seeds":            "B2/S",
+	"daynight":         "B3678/S34678",
+	"dayandnight":      "B3678/S34678",
+	"replicator":       "B1357/S1357",
+	"lifewithoutdeath": "B3/S012345678",
+	"briansbrain":      "B2/S/C3",
+	"starwars":         "B2/S345/C4",
+	"bosco":            "R5,C0,M1,S34..58,B34..45,NM",
+	"critters":         "MS,D15;14;13;3;11;5;6;1;7;9;10;2;12;4;8;0",
+	"bbm":              "MS,D0;8;4;3;2;5;9;7;1;6;10;11;12;13;14;15",
+	"billiardball":     "MS,D0;8;4;3;2;5;9;7;1;6;10;11;12;13;14;15",
+}
+
+// namedRule gives the rule with a name, ignoring case, spaces and punctuation, e.g. "Brian's Brain".
+func namedRule(name string) (Rule, bool) {
+	key := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		}
+		return -1
+	}, name)
+	if key == strings.ToLower(wireworld) {
+		return Rule{States: 4, Range: 1, Neighbourhood: Moore, Name: wireworld}, true
+	}
+	rulestring, ok := namedRules[key]
+	if !ok {
+		return Rule{}, false
+	}
+	rule, err := ParseRule(rulestring)
+	return rule, err == nil
+}
+
+// nextWireworld returns the new value of a cell of Wireworld given its current value and its number of
+// neighbouring electron heads.
+func (r Rule) nextWireworld(cell uint8, heads int) uint8 {
+	switch r.State(cell) {
+	case 1:
+		return r.Value(2)
+	case 2:
+		return r.Value(3)
+	case 3:
+		if heads == 1 || heads == 2 {
+			return r.Value(1)
+		}
+		return cell
+	}
+	return 0
+}
@@ -0,0 +1,56 @@
+package gol
+
+// Noise makes a rule stochastic: a dead cell that the rule makes alive is only born with probability Birth,
+// and an alive cell that the rule keeps alive dies anyway with probability Death, going on to its first
+// dying state for rules with more than two states.
+//
+// The random numbers come from a hash of Seed, the turn and the coordinates of the cell instead of a
+// random number generator with a state, so a run gives the same worlds whatever the number of threads
+// and wherever the strips of the workers start.
+type Noise struct {
+	Birth float64 // 1 if 0, so that a rule without noise is deterministic
+	Death float64
+	Seed  int64
+}
+
+// active tells whether the noise changes anything.
+func (n Noise) active() bool {
+	return (n.Birth > 0 && n.Birth < 1) || n.Death > 0
+}
+
+// random gives a number from 0 to 1 for a cell and a turn, the same every time it is called with them.
+func (n Noise) random(turn, x, y int) float64 {
+	h := uint64(n.Seed)
+	for _, v := range [3]uint64{uint64(turn), uint64(x), uint64(y)} {
+		h = mix(h ^ mix(v+0x9E3779B97F4A7C15))
+	}
+	//the top 53 bits fill the mantissa of a float64
+	return float64(h>>11) / (1 << 53)
+}
+
+// mix is the finaliser of SplitMix64, which spreads every bit of x over the whole result.
+func mix(x uint64) uint64 {
+	x = (x ^ (x >> 30)) * 0xBF58476D1CE4E5B9
+	x = (x ^ (x >> 27)) * 0x94D049BB133111EB
+	return x ^ (x >> 31)
+}
+
+// apply changes the value the rule gave cell x, y from before to after in the turn after turn,
+// stopping a birth or adding a death.
+func (n Noise) apply(before, after uint8, turn, x, y int, rule Rule) uint8 {
+	switch {
+	case before == 0 && after == 0xFF:
+		if n.Birth > 0 && n.random(turn, x, y) >= n.Birth {
+			return 0
+		}
+	case before == 0xFF && after == 0xFF:
+		if n.random(turn, x, y) < n.Death {
+			//the same as failing the survival conditions
+			if rule.states() > 2 {
+				return rule.Value(2)
+			}
+			return 0
+		}
+	}
+	return after
+}
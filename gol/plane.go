@@ -0,0 +1,355 @@
+package gol
+
+import (
+	"fmt"
+	"sort"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// planeChunk is the side of the square chunks that the unbounded plane is made of.
+const planeChunk = 64
+
+// chunkKey is the position of a chunk on the plane: chunk x, y holds the cells from planeChunk*x to
+// planeChunk*x+planeChunk-1 across and from planeChunk*y to planeChunk*y+planeChunk-1 down.
+type chunkKey struct {
+	x, y int
+}
+
+// plane is the world of an unbounded run, see Params.Unbounded. It only keeps the chunks with a cell that is
+// not dead, so it grows as the cells reach the edge of the chunks they are in and shrinks as chunks die out.
+// The loaded world starts with its top-left corner at 0, 0 and cells can go anywhere from there, negative
+// coordinates included.
+type plane struct {
+	chunks map[chunkKey][][]uint8
+}
+
+// unbounded checks that a run can take place on the unbounded plane, which has no edges to wrap around.
+func unbounded(p Params, rule Rule) error {
+	switch {
+	case p.Turmite != "":
+		return fmt.Errorf("turmites cannot run on the unbounded plane")
+	case rule.margolus():
+		return fmt.Errorf("Margolus rule %v cannot run on the unbounded plane", rule)
+	case rule.Neighbourhood == Hexagonal:
+		return fmt.Errorf("hexagonal rule %v cannot run on the unbounded plane", rule)
+	case rule.born(0):
+		//a dead cell with no alive neighbours is born, so every cell of the plane would be alive after a turn
+		return fmt.Errorf("rule %v gives birth to cells without alive neighbours, which the unbounded plane cannot hold", rule)
+	case rule.depth() > planeChunk:
+		return fmt.Errorf("rule %v reaches further than the chunks of the unbounded plane, %d cells", rule, planeChunk)
+	case tiled(p):
+		return fmt.Errorf("the unbounded plane is already split into chunks, it cannot be split into tiles")
+	case p.Stats || p.StatsFile != "":
+		return fmt.Errorf("stats cannot be kept on the unbounded plane")
+	}
+	return nil
+}
+
+// floorDiv divides a by b rounding down, so that the cells left of 0 go to the chunks left of chunk 0.
+func floorDiv(a, b int) int {
+	if a < 0 {
+		return -((-a + b - 1) / b)
+	}
+	return a / b
+}
+
+// chunkOf gives the chunk of cell x, y and where the cell is in it.
+func chunkOf(x, y int) (chunkKey, int, int) {
+	key := chunkKey{floorDiv(x, planeChunk), floorDiv(y, planeChunk)}
+	return key, x - key.x*planeChunk, y - key.y*planeChunk
+}
+
+func newChunk() [][]uint8 {
+	return emptyWorld(planeChunk, planeChunk)
+}
+
+// newPlane puts world on an empty plane with its top-left corner at 0, 0.
+func newPlane(world [][]uint8) *plane {
+	pl := &plane{chunks: make(map[chunkKey][][]uint8)}
+	for y, row := range world {
+		for x, cell := range row {
+			if cell != 0 {
+				pl.set(x, y, cell)
+			}
+		}
+	}
+	return pl
+}
+
+// place gives where cell x, y is, which is just x, y as the plane has no edges.
+func (pl *plane) place(x, y int) (int, int) {
+	return x, y
+}
+
+// cell gives the value of cell x, y.
+func (pl *plane) cell(x, y int) uint8 {
+	key, i, j := chunkOf(x, y)
+	if chunk, ok := pl.chunks[key]; ok {
+		return chunk[j][i]
+	}
+	return 0
+}
+
+// set changes the value of cell x, y, adding its chunk if it is not there yet.
+// Chunks that become empty stay until the next turn.
+func (pl *plane) set(x, y int, value uint8) {
+	key, i, j := chunkOf(x, y)
+	chunk, ok := pl.chunks[key]
+	if !ok {
+		if value == 0 {
+			return
+		}
+		chunk = newChunk()
+		pl.chunks[key] = chunk
+	}
+	chunk[j][i] = value
+}
+
+// keys gives the keys of the chunks, row by row, so that everything done chunk by chunk has the same order
+// in every run.
+func (pl *plane) keys() []chunkKey {
+	keys := make([]chunkKey, 0, len(pl.chunks))
+	for key := range pl.chunks {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		if keys[a].y != keys[b].y {
+			return keys[a].y < keys[b].y
+		}
+		return keys[a].x < keys[b].x
+	})
+	return keys
+}
+
+// alive gives the alive cells, row by row.
+func (pl *plane) alive() []util.Cell {
+	var cells []util.Cell
+	keys := pl.keys()
+	//the chunks of a row of chunks are gone through together, so that the cells come out row by row
+	for start := 0; start < len(keys); {
+		end := start
+		for end < len(keys) && keys[end].y == keys[start].y {
+			end++
+		}
+		for j := 0; j < planeChunk; j++ {
+			for _, key := range keys[start:end] {
+				for i, cell := range pl.chunks[key][j] {
+					if cell == 0xFF {
+						cells = append(cells, util.Cell{X: key.x*planeChunk + i, Y: key.y*planeChunk + j})
+					}
+				}
+			}
+		}
+		start = end
+	}
+	return cells
+}
+
+// count gives the number of alive cells.
+func (pl *plane) count() int {
+	count := 0
+	for _, chunk := range pl.chunks {
+		for _, row := range chunk {
+			for _, cell := range row {
+				if cell == 0xFF {
+					count++
+				}
+			}
+		}
+	}
+	return count
+}
+
+// bounds gives the top-left and bottom-right corners of the smallest rectangle around every cell that is not
+// dead, and false if every cell is dead.
+func (pl *plane) bounds() (util.Cell, util.Cell, bool) {
+	var min, max util.Cell
+	found := false
+	for key, chunk := range pl.chunks {
+		for j, row := range chunk {
+			for i, cell := range row {
+				if cell == 0 {
+					continue
+				}
+				x, y := key.x*planeChunk+i, key.y*planeChunk+j
+				if !found {
+					min, max, found = util.Cell{X: x, Y: y}, util.Cell{X: x, Y: y}, true
+					continue
+				}
+				if x < min.X {
+					min.X = x
+				}
+				if y < min.Y {
+					min.Y = y
+				}
+				if x > max.X {
+					max.X = x
+				}
+				if y > max.Y {
+					max.Y = y
+				}
+			}
+		}
+	}
+	return min, max, found
+}
+
+// image gives the cells inside the bounds of the plane as a world, with the position of its top-left corner.
+// An empty plane gives a world of a single dead cell at 0, 0.
+func (pl *plane) image() ([][]uint8, util.Cell) {
+	min, max, found := pl.bounds()
+	if !found {
+		return emptyWorld(1, 1), util.Cell{}
+	}
+	world := emptyWorld(max.X-min.X+1, max.Y-min.Y+1)
+	for y := range world {
+		for x := range world[y] {
+			world[y][x] = pl.cell(min.X+x, min.Y+y)
+		}
+	}
+	return world, min
+}
+
+// planeState outputs the bounds of the plane to a PGM file named after its size, the turn and the position
+// of its top-left corner, like 40x27x100_-12_5.
+func planeState(pl *plane, currentTurn int, c distributorChannels) {
+	world, corner := pl.image()
+	filename := fmt.Sprintf("%dx%dx%d_%d_%d", len(world[0]), len(world), currentTurn, corner.X, corner.Y)
+	c.writeWorld(filename, world)
+	c.waitIo()
+	c.send(ImageOutputComplete{CompletedTurns: currentTurn, Filename: filename})
+}
+
+// grown gives the chunks to compute in the next turn: every chunk of the plane, and the chunks next to them
+// that an alive cell is near enough to for a cell in them to be born.
+func (pl *plane) grown(depth int) []chunkKey {
+	next := make(map[chunkKey]bool, len(pl.chunks))
+	for key, chunk := range pl.chunks {
+		next[key] = true
+		//near[0] and near[2] are the chunks to the left and right, or above and below, near[1] the chunk itself
+		var nearX, nearY [3]bool
+		nearX[1], nearY[1] = true, true
+		for j, row := range chunk {
+			for i, cell := range row {
+				if cell != 0xFF {
+					continue
+				}
+				nearX[0] = nearX[0] || i < depth
+				nearX[2] = nearX[2] || i >= planeChunk-depth
+				nearY[0] = nearY[0] || j < depth
+				nearY[2] = nearY[2] || j >= planeChunk-depth
+			}
+		}
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				if nearX[dx+1] && nearY[dy+1] {
+					next[chunkKey{key.x + dx, key.y + dy}] = true
+				}
+			}
+		}
+	}
+	keys := make([]chunkKey, 0, len(next))
+	for key := range next {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// chunkHalo gives the cells of the chunk at t with depth cells of halo on every side, taken from the chunks
+// around it, like tileHalo.
+func (pl *plane) chunkHalo(t tile, depth int) [][]uint8 {
+	key, _, _ := chunkOf(t.x, t.y)
+	var around [3][3][][]uint8
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			around[dy+1][dx+1] = pl.chunks[chunkKey{key.x + dx, key.y + dy}]
+		}
+	}
+	//part gives the chunk around t that the cell at offset o of t is in, and where in that chunk it is
+	part := func(o int) (int, int) {
+		switch {
+		case o < 0:
+			return 0, o + planeChunk
+		case o >= planeChunk:
+			return 2, o - planeChunk
+		}
+		return 1, o
+	}
+	block := emptyWorld(t.width+2*depth, t.height+2*depth)
+	for j := range block {
+		cy, y := part(j - depth)
+		for i := range block[j] {
+			cx, x := part(i - depth)
+			if chunk := around[cy][cx]; chunk != nil {
+				block[j][i] = chunk[y][x]
+			}
+		}
+	}
+	return block
+}
+
+// planeWorker computes the chunks it takes from the queue until the queue is empty, writing every chunk into
+// its own chunk of next, which is already there. A chunk is computed with its halo like a tile, see tileWorker.
+func planeWorker(pl *plane, next *plane, take func() (tile, bool), rule Rule, c distributorChannels, turn int, done func()) {
+	depth := rule.depth()
+	plain := rule
+	plain.noise = Noise{}
+	for t, ok := take(); ok; t, ok = take() {
+		block := pl.chunkHalo(t, depth)
+		after := worldAfterOneTurn(t.width+2*depth, block[depth:depth+t.height], block[:depth], block[depth+t.height:],
+			t.y, plain, distributorChannels{}, turn, nil, nil)
+		key, _, _ := chunkOf(t.x, t.y)
+		chunk := next.chunks[key]
+		for h := 0; h < t.height; h++ {
+			for w := 0; w < t.width; w++ {
+				before := block[depth+h][depth+w]
+				cell := after[h][depth+w]
+				if rule.noise.active() {
+					cell = rule.noise.apply(before, cell, turn, t.x+w, t.y+h, rule)
+				}
+				chunk[h][w] = cell
+				if cell != before {
+					c.cellChanged(turn, util.Cell{X: t.x + w, Y: t.y + h}, before, cell, rule)
+				}
+			}
+		}
+	}
+	done()
+}
+
+// step computes one turn of the plane with threads workers, which take the chunks from a queue like tiles,
+// and gives the plane after it without the chunks that died out.
+func (pl *plane) step(rule Rule, threads int, c distributorChannels, turn int) *plane {
+	keys := pl.grown(rule.depth())
+	next := &plane{chunks: make(map[chunkKey][][]uint8, len(keys))}
+	chunks := make([]tile, len(keys))
+	for i, key := range keys {
+		next.chunks[key] = newChunk()
+		chunks[i] = tile{x: key.x * planeChunk, y: key.y * planeChunk, width: planeChunk, height: planeChunk}
+	}
+	take := c.takeTiles(chunks)
+	done, wait := c.join(threads)
+	for thread := 0; thread < threads; thread++ {
+		go planeWorker(pl, next, take, rule, c, turn, done)
+	}
+	wait()
+	for key, chunk := range next.chunks {
+		if emptyChunk(chunk) {
+			delete(next.chunks, key)
+		}
+	}
+	return next
+}
+
+// emptyChunk tells whether every cell of a chunk is dead.
+func emptyChunk(chunk [][]uint8) bool {
+	for _, row := range chunk {
+		for _, cell := range row {
+			if cell != 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,296 @@
+package gol
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Rule holds the birth and survival conditions of a life-like cellular automaton.
+// Birth[n] is true if a dead cell with n alive neighbours becomes alive,
+// Survive[n] is true if an alive cell with n alive neighbours stays alive.
+//
+// Rules of the Generations family have more than two States: an alive cell that does not survive
+// goes through States-2 dying states, one per turn, before it is dead. Dying cells do not count as
+// alive neighbours and cannot be born again until they are dead.
+// In the world, and in PGM images, state 0 is stored as 0 (black), state 1 as 0xFF (white) and the
+// dying states as evenly spaced, darker grey levels, see Rule.Value.
+//
+// Larger than Life rules count the alive cells within Range of a cell in the given Neighbourhood,
+// and use BirthIntervals and SurviveIntervals instead of Birth and Survive, see parseLargerThanLife.
+type Rule struct {
+	Birth   [9]bool
+	Survive [9]bool
+	States  int // 2 for life-like rules, 0 is taken as 2
+
+	Range            int // 1 for life-like rules, 0 is taken as 1
+	Neighbourhood    Neighbourhood
+	Middle           bool // the cell itself counts as one of its neighbours
+	BirthIntervals   []Interval
+	SurviveIntervals []Interval
+
+	// Margolus is the table of 16 blocks of a Margolus block rule, see margolus.go, nil for other rules.
+	Margolus []int
+
+	// Name is set for rules that have no rulestring, like Wireworld, see named.go.
+	Name string
+
+	// noise is Params.Noise, which the distributor adds to the rule of a run.
+	noise Noise
+
+	// table gives the next state of a cell for every configuration of its 3x3 block, for isotropic
+	// non-totalistic rules in Hensel notation, see hensel.go. It is nil for outer-totalistic rules.
+	table []bool
+
+	// lookup is the table of 4x4 blocks of lookup.go, which the distributor adds to the rule of a run with
+	// Params.Lookup. It is nil otherwise.
+	lookup []uint8
+}
+
+// conway is the rule used when Params.Rule is left empty.
+const conway = "B3/S23"
+
+// maxStates is the largest number of states a Generations rule can have, one per grey level.
+const maxStates = 256
+
+// ParseRule reads a rulestring in B/S notation, e.g. "B3/S23" for Conway's Game of Life or "B36/S23" for HighLife.
+// The older S/B form "23/3" is accepted too. An empty rulestring gives Conway's Game of Life.
+// A third part gives the number of states of a Generations rule, e.g. "B2/S/C3" for Brian's Brain,
+// or "345/2/4" for Star Wars in the older S/B/C form.
+// Letters after a neighbour count give an isotropic non-totalistic rule in Hensel notation, e.g. "B2-a/S12".
+// A final H or V runs the rule on a hexagonal or von Neumann grid, e.g. "B2/S34H" or "B2/S013V", see grid.go.
+// Larger than Life rules start with their range, e.g. "R5,C0,M1,S34..58,B34..45,NM", see parseLargerThanLife.
+// Margolus block rules start with an M, e.g. "MS,D0;8;4;3;2;5;9;7;1;6;10;11;12;13;14;15", see parseMargolus.
+// Well-known rules can also be given by name, e.g. "HighLife", "Brian's Brain" or "Wireworld", see named.go.
+func ParseRule(rulestring string) (Rule, error) {
+	rule := Rule{States: 2, Range: 1, Neighbourhood: Moore}
+	if rulestring == "" {
+		rulestring = conway
+	}
+	if named, ok := namedRule(rulestring); ok {
+		return named, nil
+	}
+	if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(rulestring)), "R") {
+		return parseLargerThanLife(rulestring)
+	}
+	if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(rulestring)), "M") {
+		return parseMargolus(rulestring)
+	}
+	body := strings.TrimSpace(rulestring)
+	if body != "" {
+		switch body[len(body)-1] {
+		case 'H', 'h':
+			rule.Neighbourhood = Hexagonal
+		case 'V', 'v':
+			rule.Neighbourhood = VonNeumann
+		}
+		if rule.Neighbourhood != Moore {
+			body = body[:len(body)-1]
+		}
+	}
+	parts := strings.Split(body, "/")
+	if len(parts) != 2 && len(parts) != 3 {
+		return rule, fmt.Errorf("rule %q should have two or three parts separated by '/'", rulestring)
+	}
+	//the B, S and C are upper case, the letters of Hensel notation lower case
+	hensel := false
+	for i, part := range parts {
+		if part == "" {
+			continue
+		}
+		parts[i] = strings.ToUpper(part[:1]) + strings.ToLower(part[1:])
+		if strings.ContainsAny(parts[i][1:], "abcdefghijklmnopqrstuvwxyz-") {
+			hensel = true
+		}
+	}
+	//without B and S letters the survival conditions come first
+	if !strings.HasPrefix(parts[0], "B") && !strings.HasPrefix(parts[0], "S") {
+		parts[0], parts[1] = "S"+parts[0], "B"+parts[1]
+		if len(parts) == 3 {
+			parts[2] = "C" + parts[2]
+		}
+	}
+	for _, part := range parts {
+		var counts *[9]bool
+		switch {
+		case strings.HasPrefix(part, "B"):
+			counts = &rule.Birth
+		case strings.HasPrefix(part, "S"):
+			counts = &rule.Survive
+		case strings.HasPrefix(part, "C") || strings.HasPrefix(part, "G"):
+			states, err := strconv.Atoi(part[1:])
+			if err != nil || states < 2 || states > maxStates {
+				return rule, fmt.Errorf("rule %q: %q should give between 2 and %d states", rulestring, part, maxStates)
+			}
+			rule.States = states
+			continue
+		default:
+			return rule, fmt.Errorf("rule %q: part %q should start with B, S or C", rulestring, part)
+		}
+		if hensel {
+			if rule.Neighbourhood != Moore {
+				return rule, fmt.Errorf("rule %q: Hensel notation needs the 8 neighbours of the square grid", rulestring)
+			}
+			if rule.table == nil {
+				rule.table = make([]bool, 512)
+			}
+			if err := parseHensel(rule.table, part[1:], counts == &rule.Survive); err != nil {
+				return rule, fmt.Errorf("rule %q: %v", rulestring, err)
+			}
+			continue
+		}
+		for _, digit := range part[1:] {
+			if digit < '0' || int(digit-'0') > rule.Neighbourhood.size() {
+				return rule, fmt.Errorf("rule %q: %q is not a neighbour count", rulestring, digit)
+			}
+			counts[digit-'0'] = true
+		}
+	}
+	return rule, nil
+}
+
+// String gives the rule back in B/S notation, with the H or V of other grids, in Larger than Life notation
+// or in the Margolus notation of Golly.
+// Rules without a rulestring, like Wireworld, give their name.
+func (r Rule) String() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	if r.largerThanLife() {
+		return r.largerThanLifeString()
+	}
+	if r.margolus() {
+		return r.margolusString()
+	}
+	if r.table != nil {
+		rulestring := "B" + henselString(r.table, false) + "/S" + henselString(r.table, true)
+		if r.states() > 2 {
+			rulestring += fmt.Sprintf("/C%d", r.States)
+		}
+		return rulestring
+	}
+	var b, s strings.Builder
+	for n := 0; n <= 8; n++ {
+		if r.Birth[n] {
+			b.WriteByte(byte('0' + n))
+		}
+		if r.Survive[n] {
+			s.WriteByte(byte('0' + n))
+		}
+	}
+	rulestring := "B" + b.String() + "/S" + s.String()
+	if r.states() > 2 {
+		rulestring += fmt.Sprintf("/C%d", r.States)
+	}
+	switch r.Neighbourhood {
+	case Hexagonal:
+		rulestring += "H"
+	case VonNeumann:
+		rulestring += "V"
+	}
+	return rulestring
+}
+
+// largerThanLife tells whether the rule uses BirthIntervals and SurviveIntervals instead of Birth and Survive.
+func (r Rule) largerThanLife() bool {
+	return r.Range > 1 || r.BirthIntervals != nil || r.SurviveIntervals != nil
+}
+
+// depth is the number of rows of halo a worker needs above and below its strip.
+func (r Rule) depth() int {
+	if r.Range < 1 {
+		return 1
+	}
+	return r.Range
+}
+
+// isotropic tells whether the rule needs the configuration of the 3x3 block around a cell instead of the
+// number of alive neighbours.
+func (r Rule) isotropic() bool {
+	return r.table != nil
+}
+
+// born tells whether a dead cell with n alive neighbours becomes alive.
+// For isotropic rules n is the configuration of the 3x3 block around the cell.
+func (r Rule) born(n int) bool {
+	if r.table != nil {
+		return r.table[n]
+	}
+	if r.largerThanLife() {
+		return inIntervals(n, r.BirthIntervals)
+	}
+	return r.Birth[n]
+}
+
+// survives tells whether an alive cell with n alive neighbours stays alive.
+// For isotropic rules n is the configuration of the 3x3 block around the cell.
+func (r Rule) survives(n int) bool {
+	if r.table != nil {
+		return r.table[n]
+	}
+	if r.largerThanLife() {
+		return inIntervals(n, r.SurviveIntervals)
+	}
+	return r.Survive[n]
+}
+
+func (r Rule) states() int {
+	if r.States < 2 {
+		return 2
+	}
+	return r.States
+}
+
+// step is the difference between the grey levels of two following states.
+func (r Rule) step() int {
+	return 255 / (r.states() - 1)
+}
+
+// Value gives the grey level that stores a state in the world and in PGM images.
+func (r Rule) Value(state int) uint8 {
+	if state == 0 {
+		return 0
+	}
+	return uint8(255 - (state-1)*r.step())
+}
+
+// State gives the state stored as a grey level, the nearest one for levels that do not store any state.
+func (r Rule) State(value uint8) int {
+	if value == 0 {
+		return 0
+	}
+	state := 1 + (255-int(value)+r.step()/2)/r.step()
+	if state >= r.states() {
+		state = r.states() - 1
+	}
+	return state
+}
+
+// normalise turns any grey level into the value of a state, e.g. for cells read from a PGM image.
+func (r Rule) normalise(value uint8) uint8 {
+	return r.Value(r.State(value))
+}
+
+// next returns the new value of a cell given its current value and its number of alive neighbours,
+// or the configuration of the 3x3 block around it for isotropic rules.
+func (r Rule) next(cell uint8, neighbours int) uint8 {
+	if r.Name == wireworld {
+		return r.nextWireworld(cell, neighbours)
+	}
+	switch cell {
+	case 0:
+		if r.born(neighbours) {
+			return 0xFF
+		}
+		return 0
+	case 0xFF:
+		if r.survives(neighbours) {
+			return 0xFF
+		}
+	}
+	//a dying cell, or an alive one that does not survive, moves on to the next state
+	if state := r.State(cell) + 1; state < r.states() {
+		return r.Value(state)
+	}
+	return 0
+}
@@ -0,0 +1,503 @@
+package gol
+
+import (
+	"fmt"
+	"hash/fnv"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// SearchParams describes a soup search. The rule, board size and number of workers come from Params.
+type SearchParams struct {
+	Seed     int64   // seed of the first soup, soup i uses Seed+i
+	Soups    int     // number of soups to run
+	SoupSize int     // side of the random square placed in the centre of the board
+	Density  float64 // probability of each cell in the soup being alive
+	Symmetry string  // C1 for asymmetric soups, or C2, C4 or D8 like Generator
+	MaxTurns int     // soups that have not stabilised after this many turns are given up on
+	Log      string  // path of the log of rare objects, out/search_<Seed>.log if empty
+}
+
+// RareObject is an object found by a soup search that is not in commonObjects.
+type RareObject struct {
+	Seed int64  // seed of the soup the object came from
+	Turn int    // turn at which the soup stabilised
+	Code string // apgcode of the object
+}
+
+// SearchResult is the census of a finished soup search.
+type SearchResult struct {
+	Soups      int            // soups that were run
+	Unfinished int            // soups that did not stabilise within MaxTurns
+	Census     map[string]int // number of times each apgcode was seen
+	Rare       []RareObject
+}
+
+// commonObjects names the debris that appears in almost every soup of Conway's Game of Life.
+// Anything else found by a search is logged as rare.
+var commonObjects = map[string]string{
+	"xs4_33":   "block",
+	"xp2_7":    "blinker",
+	"xs6_696":  "beehive",
+	"xq4_153":  "glider",
+	"xs7_2596": "loaf",
+	"xs5_253":  "boat",
+	"xs6_356":  "ship",
+	"xs4_252":  "tub",
+	"xs8_6996": "pond",
+	"xp2_7e":   "toad",
+	"xp2_318c": "beacon",
+	"xs6_25a4": "barge",
+	"xs7_178c": "eater",
+	"xs7_25ac": "long boat",
+	"xs8_35ac": "long ship",
+}
+
+// maxObjectPeriod bounds the number of turns an isolated object is run for when working out its period.
+const maxObjectPeriod = 64
+
+// soupResult is passed from the search workers back to Search.
+type soupResult struct {
+	seed    int64
+	turn    int
+	stable  bool
+	objects []string
+}
+
+// Search runs p.Threads workers over s.Soups random soups, or one per CPU if p.Threads is 0, runs each soup
+// until the board repeats, and takes a census of the objects left behind. Rare objects are printed and logged
+// to s.Log together with the seed of their soup, so they can be reproduced.
+func Search(p Params, s SearchParams) SearchResult {
+	rule, err := ParseRule(p.Rule)
+	util.Check(err)
+	if rule.states() > 2 || rule.largerThanLife() || rule.Neighbourhood != Moore || rule.margolus() {
+		//apgcodes here only describe objects of two-state rules with the 8 nearest neighbours of the square grid
+		util.Check(fmt.Errorf("rule %v is not life-like, the search only works with two-state range 1 rules on the square grid", rule))
+	}
+	_, err = s.generator(s.Seed).world(p.ImageWidth, p.ImageHeight, rule)
+	util.Check(err)
+
+	path := s.Log
+	if path == "" {
+		_ = os.Mkdir("out", os.ModePerm)
+		path = fmt.Sprintf("out/search_%d.log", s.Seed)
+	}
+	log, ioError := os.Create(path)
+	util.Check(ioError)
+	defer log.Close()
+	_, _ = fmt.Fprintf(log, "# rule %v, board %dx%d, %v soup %dx%d at density %v\n",
+		rule, p.ImageWidth, p.ImageHeight, s.Symmetry, s.SoupSize, s.SoupSize, s.Density)
+	_, _ = fmt.Fprintf(log, "# reproduce with: go run . -rule %v -w %d -h %d -gen %v -size %d -density %v -seed <seed>\n",
+		rule, p.ImageWidth, p.ImageHeight, s.generator(0).Kind, s.SoupSize, s.Density)
+
+	if p.Threads < 1 {
+		p.Threads = runtime.GOMAXPROCS(0)
+	}
+	var next int64 = -1
+	results := make(chan soupResult)
+	for thread := 0; thread < p.Threads; thread++ {
+		go func() {
+			for {
+				i := atomic.AddInt64(&next, 1)
+				if i >= int64(s.Soups) {
+					return
+				}
+				results <- runSoup(p, s, rule, s.Seed+i)
+			}
+		}()
+	}
+
+	result := SearchResult{Census: make(map[string]int)}
+	for result.Soups < s.Soups {
+		soup := <-results
+		result.Soups++
+		if !soup.stable {
+			result.Unfinished++
+		}
+		//soups that never settled are only counted, their debris would flood the census
+		for _, code := range soup.objects {
+			result.Census[code]++
+			if _, common := commonObjects[code]; !common {
+				rare := RareObject{Seed: soup.seed, Turn: soup.turn, Code: code}
+				result.Rare = append(result.Rare, rare)
+				fmt.Println("Found", code, "in soup", soup.seed)
+				_, _ = fmt.Fprintf(log, "%d\t%d\t%s\n", soup.seed, soup.turn, code)
+			}
+		}
+		if result.Soups%1000 == 0 {
+			fmt.Println("Searched", result.Soups, "soups")
+		}
+	}
+	//the objects of a soup are sorted too, so that the result does not depend on the order of the workers
+	sort.Slice(result.Rare, func(i, j int) bool {
+		a, b := result.Rare[i], result.Rare[j]
+		return a.Seed < b.Seed || a.Seed == b.Seed && a.Code < b.Code
+	})
+	return result
+}
+
+// CommonName gives the usual name of an object found by Search, or an empty string.
+func CommonName(code string) string {
+	return commonObjects[code]
+}
+
+// generator gives the Generator that makes the soup with the given seed.
+func (s SearchParams) generator(seed int64) Generator {
+	kind := s.Symmetry
+	if kind == "" || kind == "C1" {
+		kind = "box"
+	}
+	return Generator{Kind: kind, Seed: seed, Density: s.Density, Size: s.SoupSize}
+}
+
+func emptyWorld(width, height int) [][]uint8 {
+	world := make([][]uint8, height)
+	for y := range world {
+		world[y] = make([]uint8, width)
+	}
+	return world
+}
+
+// nextGeneration steps a whole world on the calling goroutine, without reporting any events.
+func nextGeneration(world [][]uint8, rule Rule) [][]uint8 {
+	above, below := halo(world, 0, len(world)-1, rule.depth())
+	return worldAfterOneTurn(len(world[0]), world, above, below, 0, rule, distributorChannels{}, 0, nil, nil)
+}
+
+func hashWorld(world [][]uint8) uint64 {
+	hash := fnv.New64a()
+	for _, row := range world {
+		_, _ = hash.Write(row)
+	}
+	return hash.Sum64()
+}
+
+func equalWorlds(a, b [][]uint8) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for y := range a {
+		if string(a[y]) != string(b[y]) {
+			return false
+		}
+	}
+	return true
+}
+
+// runSoup runs a single soup until the board repeats itself and then takes its census.
+func runSoup(p Params, s SearchParams, rule Rule, seed int64) soupResult {
+	world, err := s.generator(seed).world(p.ImageWidth, p.ImageHeight, rule)
+	util.Check(err)
+	seen := map[uint64]int{hashWorld(world): 0}
+	for turn := 1; turn <= s.MaxTurns; turn++ {
+		world = nextGeneration(world, rule)
+		hash := hashWorld(world)
+		if _, repeated := seen[hash]; repeated {
+			return soupResult{seed: seed, turn: turn, stable: true, objects: census(world, rule)}
+		}
+		seen[hash] = turn
+	}
+	return soupResult{seed: seed, turn: s.MaxTurns, stable: false}
+}
+
+// census splits the world into objects and names each of them with its apgcode.
+// Groups of touching cells that do not make sense on their own, such as the halves of a bi-block-like constellation,
+// are joined with every group up to two cells away, as cells that close together affect each other's neighbours.
+func census(world [][]uint8, rule Rule) []string {
+	height, width := len(world), len(world[0])
+	labels := make([][]int, height)
+	for y := range labels {
+		labels[y] = make([]int, width)
+	}
+	//flood fill each object, wrapping around the edges of the board; labels start at 1 so 0 means unlabelled
+	var objects [][]util.Cell
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if world[y][x] == 0 || labels[y][x] != 0 {
+				continue
+			}
+			var cells []util.Cell
+			stack := []util.Cell{{X: x, Y: y}}
+			labels[y][x] = len(objects) + 1
+			for len(stack) > 0 {
+				cell := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				cells = append(cells, cell)
+				for _, n := range surrounding(cell, 1, width, height) {
+					if world[n.Y][n.X] != 0 && labels[n.Y][n.X] == 0 {
+						labels[n.Y][n.X] = len(objects) + 1
+						stack = append(stack, n)
+					}
+				}
+			}
+			objects = append(objects, cells)
+		}
+	}
+
+	codes := make([]string, len(objects))
+	groups := make([]int, len(objects))
+	for i, cells := range objects {
+		groups[i] = i
+		codes[i] = classify(unwrap(cells, width, height), rule)
+	}
+	var root func(i int) int
+	root = func(i int) int {
+		for groups[i] != i {
+			i = groups[i]
+		}
+		return i
+	}
+	merged := false
+	for i, cells := range objects {
+		if !strings.HasPrefix(codes[i], "zz") {
+			continue
+		}
+		for _, cell := range cells {
+			for _, n := range surrounding(cell, 2, width, height) {
+				if label := labels[n.Y][n.X]; label != 0 && root(label-1) != root(i) {
+					groups[root(label-1)] = root(i)
+					merged = true
+				}
+			}
+		}
+	}
+	if !merged {
+		return codes
+	}
+	members := make(map[int][]util.Cell)
+	var roots []int
+	for i, cells := range objects {
+		if _, ok := members[root(i)]; !ok {
+			roots = append(roots, root(i))
+		}
+		members[root(i)] = append(members[root(i)], cells...)
+	}
+	//the objects are listed in the order of their roots, not that of the map, so that the census is the same every run
+	sort.Ints(roots)
+	var census []string
+	for _, i := range roots {
+		cells := members[i]
+		if len(cells) == len(objects[i]) {
+			//nothing was joined to this object, so it keeps its code
+			census = append(census, codes[i])
+		} else {
+			census = append(census, classify(unwrap(cells, width, height), rule))
+		}
+	}
+	return census
+}
+
+// surrounding lists the cells up to distance away from a cell, wrapping around the edges of the board.
+func surrounding(cell util.Cell, distance, width, height int) []util.Cell {
+	var cells []util.Cell
+	for j := -distance; j <= distance; j++ {
+		for i := -distance; i <= distance; i++ {
+			if i != 0 || j != 0 {
+				cells = append(cells, util.Cell{X: (cell.X + width + i) % width, Y: (cell.Y + height + j) % height})
+			}
+		}
+	}
+	return cells
+}
+
+// unwrap moves the cells of an object that straddles the edge of the board back next to each other,
+// and returns the object cropped to its bounding box.
+func unwrap(cells []util.Cell, width, height int) [][]uint8 {
+	//the object is rebuilt from its first cell outwards, so every cell ends up next to the cell it was found from
+	placed := map[util.Cell]util.Cell{cells[0]: cells[0]}
+	for changed := true; changed; {
+		changed = false
+		for _, cell := range cells {
+			if _, ok := placed[cell]; ok {
+				continue
+			}
+			for j := -2; j <= 2 && !changed; j++ {
+				for i := -2; i <= 2 && !changed; i++ {
+					neighbour := util.Cell{X: (cell.X + width + i) % width, Y: (cell.Y + height + j) % height}
+					if at, ok := placed[neighbour]; ok {
+						placed[cell] = util.Cell{X: at.X - i, Y: at.Y - j}
+						changed = true
+					}
+				}
+			}
+		}
+	}
+	unwrapped := make([]util.Cell, 0, len(placed))
+	for _, at := range placed {
+		unwrapped = append(unwrapped, at)
+	}
+	pattern, _, _ := crop(unwrapped)
+	return pattern
+}
+
+// crop draws a set of cells into the smallest pattern that holds them. It also returns the top-left corner.
+func crop(cells []util.Cell) ([][]uint8, int, int) {
+	if len(cells) == 0 {
+		return nil, 0, 0
+	}
+	minX, minY, maxX, maxY := cells[0].X, cells[0].Y, cells[0].X, cells[0].Y
+	for _, cell := range cells {
+		if cell.X < minX {
+			minX = cell.X
+		}
+		if cell.X > maxX {
+			maxX = cell.X
+		}
+		if cell.Y < minY {
+			minY = cell.Y
+		}
+		if cell.Y > maxY {
+			maxY = cell.Y
+		}
+	}
+	pattern := emptyWorld(maxX-minX+1, maxY-minY+1)
+	for _, cell := range cells {
+		pattern[cell.Y-minY][cell.X-minX] = 0xFF
+	}
+	return pattern, minX, minY
+}
+
+// classify runs an object on its own to find its period and gives it an apgcode:
+// xs<population> for still lifes, xp<period> for oscillators, xq<period> for spaceships,
+// and zz for anything that does not repeat within maxObjectPeriod turns.
+func classify(pattern [][]uint8, rule Rule) string {
+	//leave enough room around the object for a spaceship to travel without meeting itself
+	margin := maxObjectPeriod/2 + 2
+	width, height := len(pattern[0])+2*margin, len(pattern)+2*margin
+	world := emptyWorld(width, height)
+	population := 0
+	for y, row := range pattern {
+		for x, cell := range row {
+			world[y+margin][x+margin] = cell
+			if cell != 0 {
+				population++
+			}
+		}
+	}
+	phases := [][][]uint8{pattern}
+	_, startX, startY := crop(computeAliveCell(world))
+	for period := 1; period <= maxObjectPeriod; period++ {
+		world = nextGeneration(world, rule)
+		phase, x, y := crop(computeAliveCell(world))
+		if phase == nil {
+			return "zz_dies"
+		}
+		if equalWorlds(phase, pattern) {
+			code := canonicalCode(phases)
+			switch {
+			case x != startX || y != startY:
+				return fmt.Sprintf("xq%d_%s", period, code)
+			case period == 1:
+				return fmt.Sprintf("xs%d_%s", population, code)
+			default:
+				return fmt.Sprintf("xp%d_%s", period, code)
+			}
+		}
+		phases = append(phases, phase)
+	}
+	return "zz_" + canonicalCode([][][]uint8{pattern})
+}
+
+// canonicalCode picks the shortest, then alphabetically first, Wechsler code over every phase and orientation.
+func canonicalCode(phases [][][]uint8) string {
+	best := ""
+	for _, phase := range phases {
+		for _, oriented := range orientations(phase) {
+			code := wechsler(oriented)
+			if best == "" || len(code) < len(best) || (len(code) == len(best) && code < best) {
+				best = code
+			}
+		}
+	}
+	return best
+}
+
+// orientations gives the eight rotations and reflections of a pattern.
+func orientations(pattern [][]uint8) [][][]uint8 {
+	var result [][][]uint8
+	for _, transpose := range []bool{false, true} {
+		for _, flipX := range []bool{false, true} {
+			for _, flipY := range []bool{false, true} {
+				height, width := len(pattern), len(pattern[0])
+				if transpose {
+					width, height = height, width
+				}
+				oriented := emptyWorld(width, height)
+				for y := 0; y < height; y++ {
+					for x := 0; x < width; x++ {
+						sx, sy := x, y
+						if flipX {
+							sx = width - 1 - x
+						}
+						if flipY {
+							sy = height - 1 - y
+						}
+						if transpose {
+							sx, sy = sy, sx
+						}
+						oriented[y][x] = pattern[sy][sx]
+					}
+				}
+				result = append(result, oriented)
+			}
+		}
+	}
+	return result
+}
+
+// wechsler encodes a cropped pattern in extended Wechsler format, as used by apgcodes.
+// The pattern is cut into strips of 5 rows; each column of a strip becomes one character,
+// runs of empty columns are shortened with w, x and y, and strips are separated by z.
+func wechsler(pattern [][]uint8) string {
+	const digits = "0123456789abcdefghijklmnopqrstuv"
+	var strips []string
+	for top := 0; top < len(pattern); top += 5 {
+		var columns []byte
+		for x := range pattern[0] {
+			value := 0
+			for bit := 0; bit < 5 && top+bit < len(pattern); bit++ {
+				if pattern[top+bit][x] != 0 {
+					value |= 1 << uint(bit)
+				}
+			}
+			columns = append(columns, digits[value])
+		}
+		strips = append(strips, compressZeros(strings.TrimRight(string(columns), "0")))
+	}
+	return strings.Join(strips, "z")
+}
+
+// compressZeros replaces runs of zeros: 00 is w, 000 is x and 4 to 39 zeros are y followed by a digit.
+func compressZeros(strip string) string {
+	const digits = "0123456789abcdefghijklmnopqrstuvwxyz"
+	var out strings.Builder
+	for i := 0; i < len(strip); {
+		if strip[i] != '0' {
+			out.WriteByte(strip[i])
+			i++
+			continue
+		}
+		run := 0
+		for i+run < len(strip) && strip[i+run] == '0' && run < 39 {
+			run++
+		}
+		switch {
+		case run == 1:
+			out.WriteByte('0')
+		case run == 2:
+			out.WriteByte('w')
+		case run == 3:
+			out.WriteByte('x')
+		default:
+			out.WriteByte('y')
+			out.WriteByte(digits[run-4])
+		}
+		i += run
+	}
+	return out.String()
+}
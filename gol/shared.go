@@ -0,0 +1,253 @@
+package gol
+
+import (
+	"sync"
+	"time"
+)
+
+// The shared engine runs the same distributor as the channel engine, but its goroutines talk through
+// buffers in shared memory guarded by mutexes and condition variables instead of channels: the workers
+// leave their pieces of the world in a slice and count themselves done, the distributor hands worlds and
+// stats to the io goroutine in a shared request, and events, key presses and edits wait in queues.
+// Only Run itself still takes and gives channels, which are forwarded to and from the queues, and the events
+// are published to the Bus of the run from their queue.
+
+// sharedState is everything the goroutines of the shared engine share, see distributorChannels.shared.
+type sharedState struct {
+	events *sharedEvents
+	input  *sharedInput
+	io     *sharedIo
+}
+
+// runShared is RunWithBus for the shared engine.
+func runShared(p Params, bus *Bus, keyPresses <-chan rune, edits <-chan Edit) {
+	shared := &sharedState{events: newSharedEvents(), input: newSharedInput(), io: newSharedIo()}
+	go shared.events.forward(bus)
+	if keyPresses != nil {
+		go shared.input.forwardKeys(keyPresses)
+	}
+	if edits != nil {
+		go shared.input.forwardEdits(edits)
+	}
+	go startSharedIo(p, shared.io)
+	distributor(p, distributorChannels{events: bus, shared: shared})
+}
+
+// sharedEventsSize is how many events the distributor and workers can get ahead of whoever reads them,
+// like a buffered channel.
+const sharedEventsSize = 1000
+
+// sharedEvents is the queue of events waiting to be published to the Bus of the run.
+type sharedEvents struct {
+	mutex  sync.Mutex
+	cond   *sync.Cond
+	queue  []Event
+	closed bool
+}
+
+func newSharedEvents() *sharedEvents {
+	s := &sharedEvents{}
+	s.cond = sync.NewCond(&s.mutex)
+	return s
+}
+
+// push adds an event to the queue, waiting while the queue is full.
+func (s *sharedEvents) push(event Event) {
+	s.mutex.Lock()
+	for len(s.queue) >= sharedEventsSize {
+		s.cond.Wait()
+	}
+	s.queue = append(s.queue, event)
+	s.cond.Broadcast()
+	s.mutex.Unlock()
+}
+
+// close marks the end of the events: the bus is closed once the queue is empty.
+func (s *sharedEvents) close() {
+	s.mutex.Lock()
+	s.closed = true
+	s.cond.Broadcast()
+	s.mutex.Unlock()
+}
+
+// forward publishes the queued events to bus in order, taking everything queued at once, until close.
+func (s *sharedEvents) forward(bus *Bus) {
+	for {
+		s.mutex.Lock()
+		for len(s.queue) == 0 && !s.closed {
+			s.cond.Wait()
+		}
+		batch, closed := s.queue, s.closed
+		s.queue = nil
+		s.cond.Broadcast()
+		s.mutex.Unlock()
+		for _, event := range batch {
+			bus.Publish(event)
+		}
+		if closed && len(batch) == 0 {
+			bus.Close()
+			return
+		}
+	}
+}
+
+// sharedInput holds the key presses and edits that have not been acted on yet.
+type sharedInput struct {
+	mutex    sync.Mutex
+	cond     *sync.Cond
+	keys     []rune
+	edits    []Edit
+	lastTick time.Time
+}
+
+func newSharedInput() *sharedInput {
+	s := &sharedInput{lastTick: time.Now()}
+	s.cond = sync.NewCond(&s.mutex)
+	return s
+}
+
+// forwardKeys queues the key presses of Run as they come. It stops when keyPresses is closed.
+func (s *sharedInput) forwardKeys(keyPresses <-chan rune) {
+	for key := range keyPresses {
+		s.mutex.Lock()
+		s.keys = append(s.keys, key)
+		s.cond.Broadcast()
+		s.mutex.Unlock()
+	}
+}
+
+// forwardEdits queues the edits of RunWithEdits as they come. It stops when edits is closed.
+func (s *sharedInput) forwardEdits(edits <-chan Edit) {
+	for edit := range edits {
+		s.mutex.Lock()
+		s.edits = append(s.edits, edit)
+		s.cond.Broadcast()
+		s.mutex.Unlock()
+	}
+}
+
+// next takes the oldest key press or edit, waiting for one if block is set. Without block it gives a
+// tick every 2 seconds instead of a ticker, and nothing if there is nothing to act on.
+func (s *sharedInput) next(block bool) (input, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for block && len(s.keys) == 0 && len(s.edits) == 0 {
+		s.cond.Wait()
+	}
+	switch {
+	case !block && time.Since(s.lastTick) >= 2*time.Second:
+		s.lastTick = time.Now()
+		return input{tick: true}, true
+	case len(s.keys) > 0:
+		key := s.keys[0]
+		s.keys = s.keys[1:]
+		return input{key: key}, true
+	case len(s.edits) > 0:
+		edit := s.edits[0]
+		s.edits = s.edits[1:]
+		return input{edit: &edit}, true
+	}
+	return input{}, false
+}
+
+// sharedIo is the one request the distributor can have with the io goroutine at a time. The distributor
+// waits for the last request to be taken on before it makes the next one, and for the world it asked for
+// to be read.
+type sharedIo struct {
+	mutex    sync.Mutex
+	cond     *sync.Cond
+	busy     bool // a request is waiting or being carried out
+	command  ioCommand
+	filename string
+	world    [][]uint8 // the world to write, a copy the distributor cannot change under the io goroutine
+	input    []uint8   // the world that has been read
+	stats    TurnStats
+}
+
+func newSharedIo() *sharedIo {
+	s := &sharedIo{}
+	s.cond = sync.NewCond(&s.mutex)
+	return s
+}
+
+// request asks the io goroutine to carry out a command, waiting for the last request to be finished first.
+func (s *sharedIo) request(command ioCommand, filename string, world [][]uint8, stats TurnStats) {
+	s.mutex.Lock()
+	for s.busy {
+		s.cond.Wait()
+	}
+	s.busy, s.command, s.filename, s.world, s.stats = true, command, filename, world, stats
+	s.cond.Broadcast()
+	s.mutex.Unlock()
+}
+
+// wait waits for the last request to be finished, and gives the world it read if it was an ioInput.
+func (s *sharedIo) wait() []uint8 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for s.busy {
+		s.cond.Wait()
+	}
+	return s.input
+}
+
+// startSharedIo is the entrypoint of the io goroutine of the shared engine, the counterpart of startIo.
+func startSharedIo(p Params, s *sharedIo) {
+	io := ioState{params: p}
+	for {
+		s.mutex.Lock()
+		for !s.busy {
+			s.cond.Wait()
+		}
+		command, filename, world, stats := s.command, s.filename, s.world, s.stats
+		s.mutex.Unlock()
+
+		var read []uint8
+		switch command {
+		case ioInput:
+			if io.params.Generator.Kind != "" {
+				read = io.generate()
+			} else {
+				read = io.readImage(filename)
+			}
+		case ioOutput:
+			io.writeImage(filename, world)
+		case ioCheckIdle:
+			io.flushStats()
+		case ioStats:
+			io.appendStats(stats)
+		case ioQuit:
+			io.closeStats()
+		}
+
+		s.mutex.Lock()
+		s.busy, s.world, s.input = false, nil, read
+		s.cond.Broadcast()
+		s.mutex.Unlock()
+		if command == ioQuit {
+			return
+		}
+	}
+}
+
+// newSharedJoin gives a done to be called by each of workers workers and a wait that returns once they all
+// have, with a count guarded by a mutex and a condition variable.
+func newSharedJoin(workers int) (func(), func()) {
+	var mutex sync.Mutex
+	cond := sync.NewCond(&mutex)
+	left := workers
+	done := func() {
+		mutex.Lock()
+		left--
+		cond.Broadcast()
+		mutex.Unlock()
+	}
+	wait := func() {
+		mutex.Lock()
+		for left > 0 {
+			cond.Wait()
+		}
+		mutex.Unlock()
+	}
+	return done, wait
+}
@@ -0,0 +1,50 @@
+package gol
+
+import (
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// statsTileSize is the side of the tiles counted by TurnStats.ActiveTiles.
+const statsTileSize = 16
+
+// turnStats compares the worlds before and after a turn.
+func turnStats(before, after [][]uint8, turn int, duration time.Duration) TurnStats {
+	height, width := len(after), len(after[0])
+	stats := TurnStats{CompletedTurns: turn, Duration: duration}
+	tilesAcross := (width + statsTileSize - 1) / statsTileSize
+	active := make([]bool, tilesAcross*((height+statsTileSize-1)/statsTileSize))
+	for y, row := range after {
+		for x, cell := range row {
+			if cell == 0xFF {
+				if stats.Population == 0 {
+					stats.Min, stats.Max = util.Cell{X: x, Y: y}, util.Cell{X: x, Y: y}
+				}
+				stats.Population++
+				if x < stats.Min.X {
+					stats.Min.X = x
+				}
+				if x > stats.Max.X {
+					stats.Max.X = x
+				}
+				stats.Max.Y = y
+			}
+			//dying cells of rules with more than two states changing state are not births or deaths
+			if (cell == 0xFF) != (before[y][x] == 0xFF) {
+				if cell == 0xFF {
+					stats.Births++
+				} else {
+					stats.Deaths++
+				}
+				active[(y/statsTileSize)*tilesAcross+x/statsTileSize] = true
+			}
+		}
+	}
+	for _, tile := range active {
+		if tile {
+			stats.ActiveTiles++
+		}
+	}
+	return stats
+}
@@ -0,0 +1,135 @@
+package gol
+
+import "uk.ac.bris.cs/gameoflife/util"
+
+// activeTileSize is the side of the square tiles used to skip the parts of the world that have settled.
+const activeTileSize = 32
+
+// activity keeps track of the tiles of the world that changed in the last turn, so that the next turn only
+// recomputes the cells of those tiles and the tiles around them. A cell whose neighbours, itself included,
+// all stayed the same has the same neighbours as in the last turn, so a deterministic rule gives it the
+// same value again.
+//
+// Both halves are kept per row and tile column, so that every worker only writes the rows of its own strip.
+type activity struct {
+	changed [][]bool // set by the workers for every cell that changed in the turn
+	active  [][]bool // the cells of the tiles to recompute in the turn
+	across  int
+}
+
+func newActivity(width, height int) *activity {
+	across := (width + activeTileSize - 1) / activeTileSize
+	a := &activity{changed: make([][]bool, height), active: make([][]bool, height), across: across}
+	for y := range a.active {
+		a.changed[y] = make([]bool, across)
+		a.active[y] = make([]bool, across)
+	}
+	a.all()
+	return a
+}
+
+// sparse tells whether a run can skip the settled tiles: only deterministic rules of range 1 on the
+// square grid with the count of their neighbours are computed tile by tile, when split into strips of a board.
+func sparse(p Params, rule Rule) bool {
+	return !p.FullRecompute && !p.Unbounded && !tiled(p) && !p.Lookup && !rule.noise.active() && !rule.largerThanLife() && !rule.isotropic() &&
+		!rule.margolus() && rule.Neighbourhood == Moore && p.Turmite == ""
+}
+
+// all makes every tile active, e.g. after the world has been edited.
+func (a *activity) all() {
+	for _, row := range a.active {
+		for tx := range row {
+			row[tx] = true
+		}
+	}
+}
+
+// update makes the tiles that changed in the last turn and their 8 neighbours active for the next turn,
+// wrapping around the edges of the world like the cells.
+func (a *activity) update() {
+	height := len(a.changed)
+	down := (height + activeTileSize - 1) / activeTileSize
+	changed := make([]bool, a.across*down)
+	for y, row := range a.changed {
+		for tx, c := range row {
+			if c {
+				changed[(y/activeTileSize)*a.across+tx] = true
+				row[tx] = false
+			}
+		}
+	}
+	active := make([]bool, a.across*down)
+	for ty := 0; ty < down; ty++ {
+		for tx := 0; tx < a.across; tx++ {
+			if !changed[ty*a.across+tx] {
+				continue
+			}
+			for j := -1; j <= 1; j++ {
+				for i := -1; i <= 1; i++ {
+					active[((ty+j+down)%down)*a.across+(tx+i+a.across)%a.across] = true
+				}
+			}
+		}
+	}
+	for y, row := range a.active {
+		copy(row, active[(y/activeTileSize)*a.across:(y/activeTileSize+1)*a.across])
+	}
+}
+
+// sparseTurn computes the next state of pieceOfWorld like worldAfterOneTurn, but only for the cells of active
+// tiles, counting their neighbours one by one. Rows without any active tile are shared with the last world,
+// which is never changed once the turn is over. Every cell that changes is marked in changed.
+func sparseTurn(width int, pieceOfWorld [][]uint8, topEdge, botEdge []uint8, startY int, rule Rule, c distributorChannels, turn int, active, changed [][]bool) [][]uint8 {
+	newWorld := make([][]uint8, len(pieceOfWorld))
+	for h, row := range pieceOfWorld {
+		computed := false
+		for _, a := range active[h] {
+			computed = computed || a
+		}
+		if !computed {
+			newWorld[h] = row
+			continue
+		}
+		above, below := topEdge, botEdge
+		if h > 0 {
+			above = pieceOfWorld[h-1]
+		}
+		if h < len(pieceOfWorld)-1 {
+			below = pieceOfWorld[h+1]
+		}
+		newWorld[h] = append([]uint8(nil), row...)
+		for tx, a := range active[h] {
+			if !a {
+				continue
+			}
+			end := (tx + 1) * activeTileSize
+			if end > width {
+				end = width
+			}
+			for w := tx * activeTileSize; w < end; w++ {
+				left, right := (w-1+width)%width, (w+1)%width
+				neighbours := 0
+				for _, r := range [3][]uint8{above, row, below} {
+					if r[left] == 0xFF {
+						neighbours++
+					}
+					if r[right] == 0xFF {
+						neighbours++
+					}
+				}
+				if above[w] == 0xFF {
+					neighbours++
+				}
+				if below[w] == 0xFF {
+					neighbours++
+				}
+				newWorld[h][w] = rule.next(row[w], neighbours)
+				if newWorld[h][w] != row[w] {
+					changed[h][tx] = true
+					c.cellChanged(turn, util.Cell{X: w, Y: startY + h}, row[w], newWorld[h][w], rule)
+				}
+			}
+		}
+	}
+	return newWorld
+}
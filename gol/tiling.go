@@ -0,0 +1,132 @@
+package gol
+
+import (
+	"sync"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// tile is a rectangle of the world handed to a worker, with its top-left cell at x, y.
+type tile struct {
+	x, y, width, height int
+}
+
+// tiled tells whether p splits the world into tiles instead of strips.
+func tiled(p Params) bool {
+	return p.TileWidth > 0 || p.TileHeight > 0
+}
+
+// splitTiles cuts a width x height world into tiles of tileWidth x tileHeight, row by row. The tiles at the
+// right and bottom edges are smaller if the sides do not divide evenly, and a side of 0 spans the whole world.
+func splitTiles(width, height, tileWidth, tileHeight int) []tile {
+	if tileWidth <= 0 || tileWidth > width {
+		tileWidth = width
+	}
+	if tileHeight <= 0 || tileHeight > height {
+		tileHeight = height
+	}
+	var tiles []tile
+	for y := 0; y < height; y += tileHeight {
+		for x := 0; x < width; x += tileWidth {
+			t := tile{x: x, y: y, width: tileWidth, height: tileHeight}
+			if x+t.width > width {
+				t.width = width - x
+			}
+			if y+t.height > height {
+				t.height = height - y
+			}
+			tiles = append(tiles, t)
+		}
+	}
+	return tiles
+}
+
+// tileHalo gives the cells of t with depth cells of halo on every side, the corners included, wrapping
+// around the edges of the world. Rows 0 to depth-1 are the halo above the tile and the last depth rows the
+// halo below it, and every row has depth columns of halo on the left and on the right.
+func tileHalo(world [][]uint8, t tile, depth int) [][]uint8 {
+	height, width := len(world), len(world[0])
+	block := make([][]uint8, t.height+2*depth)
+	for j := range block {
+		row := world[((t.y-depth+j)%height+height)%height]
+		block[j] = make([]uint8, t.width+2*depth)
+		for i := range block[j] {
+			block[j][i] = row[((t.x-depth+i)%width+width)%width]
+		}
+	}
+	return block
+}
+
+// takeTiles gives a take that hands out the tiles one at a time to whichever worker asks first, and false
+// once they have all been taken: from a closed channel in the channel engine, and from an index guarded by
+// a mutex in the shared engine.
+func (c distributorChannels) takeTiles(tiles []tile) func() (tile, bool) {
+	if c.shared != nil {
+		var mutex sync.Mutex
+		next := 0
+		return func() (tile, bool) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			if next == len(tiles) {
+				return tile{}, false
+			}
+			next++
+			return tiles[next-1], true
+		}
+	}
+	queue := make(chan tile, len(tiles))
+	for _, t := range tiles {
+		queue <- t
+	}
+	close(queue)
+	return func() (tile, bool) {
+		t, ok := <-queue
+		return t, ok
+	}
+}
+
+// tileWorker computes the tiles it takes from the queue until the queue is empty, writing every tile
+// straight into its own cells of next. The tile is computed with its halo like a small world of its own,
+// whose wrapping never reaches the cells of the tile, and only then given back its place in the world.
+func tileWorker(world, next [][]uint8, take func() (tile, bool), rule Rule, c distributorChannels, turn int, done func()) {
+	depth := rule.depth()
+	//the noise depends on the coordinates of the cell, so it is applied once they are known
+	plain := rule
+	plain.noise = Noise{}
+	for t, ok := take(); ok; t, ok = take() {
+		block := tileHalo(world, t, depth)
+		after := worldAfterOneTurn(t.width+2*depth, block[depth:depth+t.height], block[:depth], block[depth+t.height:],
+			t.y, plain, distributorChannels{}, turn, nil, nil)
+		for h := 0; h < t.height; h++ {
+			y := t.y + h
+			for w := 0; w < t.width; w++ {
+				x := t.x + w
+				cell := after[h][depth+w]
+				if rule.noise.active() {
+					cell = rule.noise.apply(world[y][x], cell, turn, x, y, rule)
+				}
+				next[y][x] = cell
+				if cell != world[y][x] {
+					c.cellChanged(turn, util.Cell{X: x, Y: y}, world[y][x], cell, rule)
+				}
+			}
+		}
+	}
+	done()
+}
+
+// tiledTurn computes one turn with the world split into tiles, which threads workers take from a queue as
+// they finish the last one, so that a worker whose tiles are quick goes on to help with the rest.
+func tiledTurn(world [][]uint8, tiles []tile, threads int, rule Rule, c distributorChannels, turn int) [][]uint8 {
+	next := make([][]uint8, len(world))
+	for y := range next {
+		next[y] = make([]uint8, len(world[y]))
+	}
+	take := c.takeTiles(tiles)
+	done, wait := c.join(threads)
+	for thread := 0; thread < threads; thread++ {
+		go tileWorker(world, next, take, rule, c, turn, done)
+	}
+	wait()
+	return next
+}
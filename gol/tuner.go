@@ -0,0 +1,84 @@
+package gol
+
+import (
+	"runtime"
+	"time"
+)
+
+// tuneBatch is the number of turns each thread count is timed for, and tuneInterval the number of turns
+// between one choice and the next round of timing, which lets the count follow the population.
+const (
+	tuneBatch    = 5
+	tuneInterval = 500
+)
+
+// tuner picks the number of workers of a run with Params.Threads 0. It times a batch of turns with each
+// of its candidates, keeps the fastest for tuneInterval turns and then times them all again.
+type tuner struct {
+	candidates []int
+	elapsed    []time.Duration
+	trial      int // the candidate being timed, or len(candidates) while the choice is kept
+	turns      int // turns timed for the trial, or kept since the choice
+	chosen     int // 0 until the first choice
+}
+
+// newTuner makes a tuner with the powers of two up to the number of CPUs Go runs on, see runtime.GOMAXPROCS,
+// and that number itself as candidates. None of them is larger than most, which is the number of rows for strips.
+func newTuner(most int) *tuner {
+	cpus := runtime.GOMAXPROCS(0)
+	t := &tuner{}
+	for threads := 1; threads < cpus && threads <= most; threads *= 2 {
+		t.candidates = append(t.candidates, threads)
+	}
+	if cpus > most {
+		cpus = most
+	}
+	if len(t.candidates) == 0 || t.candidates[len(t.candidates)-1] != cpus {
+		t.candidates = append(t.candidates, cpus)
+	}
+	t.elapsed = make([]time.Duration, len(t.candidates))
+	return t
+}
+
+// threads gives the number of workers for the next turn.
+func (t *tuner) threads() int {
+	if t.trial < len(t.candidates) {
+		return t.candidates[t.trial]
+	}
+	return t.chosen
+}
+
+// most gives the largest number of workers the tuner can ask for.
+func (t *tuner) most() int {
+	return t.candidates[len(t.candidates)-1]
+}
+
+// record takes the time of the turn that has just been computed with threads() workers. It returns true
+// when it has chosen a number of workers that differs from the last choice.
+func (t *tuner) record(d time.Duration) bool {
+	t.turns++
+	if t.trial == len(t.candidates) {
+		if t.turns >= tuneInterval {
+			t.trial, t.turns = 0, 0
+		}
+		return false
+	}
+	t.elapsed[t.trial] += d
+	if t.turns < tuneBatch {
+		return false
+	}
+	t.trial, t.turns = t.trial+1, 0
+	if t.trial < len(t.candidates) {
+		return false
+	}
+	best := 0
+	for i := range t.candidates {
+		if t.elapsed[i] < t.elapsed[best] {
+			best = i
+		}
+		t.elapsed[i] = 0
+	}
+	last := t.chosen
+	t.chosen = t.candidates[best]
+	return t.chosen != last
+}
@@ -0,0 +1,245 @@
+package gol
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// Direction is where an ant is facing.
+type Direction int
+
+const (
+	North Direction = iota // up, towards row 0
+	East
+	South
+	West
+)
+
+// Ant is a turmite on the board. Ants live on the torus like the cells: moving off one edge of the board
+// brings them back on the opposite edge.
+type Ant struct {
+	X, Y      int
+	Direction Direction
+	State     int
+}
+
+// Cell gives the cell the ant is on.
+func (a Ant) Cell() util.Cell {
+	return util.Cell{X: a.X, Y: a.Y}
+}
+
+// turns of the transitions, as in the turmite notation of Golly
+const (
+	noTurn    = 1
+	turnRight = 2
+	uTurn     = 4
+	turnLeft  = 8
+)
+
+// transition is what an ant in some state does on a cell of some colour: it paints the cell, turns,
+// takes its next state and then moves forward one cell.
+type transition struct {
+	colour, turn, state int
+}
+
+// Turmite is a table of transitions for every state of the ants and every colour of the cells.
+// The colours are stored in the world like the states of a Generations rule with as many states:
+// colour 0 is black, colour 1 white and any further colours darker grey levels, see Rule.Value.
+type Turmite struct {
+	spec  string
+	table [][]transition // [state][colour]
+}
+
+// ParseTurmite reads a turmite in one of two notations. A string of turns like "RL", one letter per colour,
+// is for ants with a single state: on a cell of colour c the ant paints it colour c+1 and turns by letter c,
+// L or R, N for no turn or U for a u-turn. "RL" is Langton's ant.
+// Turmites with more states use the table notation of Golly, a {colour, turn, state} for every state and
+// colour with turns 1 for none, 2 right, 4 u-turn and 8 left, e.g. "{{{1,2,0},{0,8,0}}}" for Langton's ant.
+func ParseTurmite(spec string) (Turmite, error) {
+	spec = strings.TrimSpace(spec)
+	t := Turmite{spec: spec}
+	if strings.HasPrefix(spec, "{") {
+		//the numbers are three levels deep, and each state ends when the second level closes
+		depth := 0
+		var numbers []int
+		var number strings.Builder
+		for _, r := range spec + " " {
+			if number.Len() > 0 && (r < '0' || r > '9') {
+				n, _ := strconv.Atoi(number.String())
+				numbers = append(numbers, n)
+				number.Reset()
+			}
+			switch {
+			case r == '{':
+				depth++
+			case r == '}':
+				depth--
+				if depth == 1 {
+					if len(numbers) == 0 || len(numbers)%3 != 0 {
+						return t, fmt.Errorf("turmite %q: every state needs a {colour, turn, state} for every colour", spec)
+					}
+					var row []transition
+					for i := 0; i < len(numbers); i += 3 {
+						row = append(row, transition{numbers[i], numbers[i+1], numbers[i+2]})
+					}
+					t.table = append(t.table, row)
+					numbers = nil
+				}
+			case r >= '0' && r <= '9' && depth == 3:
+				number.WriteRune(r)
+			case r == ',' || r == ' ' || r == '\n' || r == '\t':
+			default:
+				return t, fmt.Errorf("turmite %q: unexpected %q", spec, r)
+			}
+		}
+		if depth != 0 || len(t.table) == 0 {
+			return t, fmt.Errorf("turmite %q: the brackets do not match", spec)
+		}
+	} else {
+		var row []transition
+		for i, letter := range strings.ToUpper(spec) {
+			turn := map[rune]int{'N': noTurn, 'R': turnRight, 'U': uTurn, 'L': turnLeft}[letter]
+			if turn == 0 {
+				return t, fmt.Errorf("turmite %q: %q should be L, R, N or U", spec, letter)
+			}
+			row = append(row, transition{colour: (i + 1) % len(spec), turn: turn})
+		}
+		t.table = [][]transition{row}
+	}
+
+	colours := len(t.table[0])
+	if colours < 2 || colours > maxStates {
+		return t, fmt.Errorf("turmite %q should have between 2 and %d colours", spec, maxStates)
+	}
+	for _, row := range t.table {
+		if len(row) != colours {
+			return t, fmt.Errorf("turmite %q: every state should have a transition for each of the %d colours", spec, colours)
+		}
+		for _, next := range row {
+			switch {
+			case next.colour < 0 || next.colour >= colours:
+				return t, fmt.Errorf("turmite %q: colour %d is not one of the %d colours", spec, next.colour, colours)
+			case next.turn != noTurn && next.turn != turnRight && next.turn != uTurn && next.turn != turnLeft:
+				return t, fmt.Errorf("turmite %q: turn %d should be 1, 2, 4 or 8", spec, next.turn)
+			case next.state < 0 || next.state >= len(t.table):
+				return t, fmt.Errorf("turmite %q: state %d is not one of the %d states", spec, next.state, len(t.table))
+			}
+		}
+	}
+	return t, nil
+}
+
+func (t Turmite) String() string {
+	return t.spec
+}
+
+// Colours gives the number of colours a cell can have.
+func (t Turmite) Colours() int {
+	return len(t.table[0])
+}
+
+// colourRule gives the rule that stores the colours of the turmite in the world as grey levels.
+func (t Turmite) colourRule() Rule {
+	return Rule{States: t.Colours(), Range: 1, Neighbourhood: Moore}
+}
+
+// move lets one ant act on the cell it is on and move on, on a width x height board.
+func (t Turmite) move(ant Ant, world [][]uint8, width, height int, colours Rule) Ant {
+	next := t.table[ant.State][colours.State(world[ant.Y][ant.X])]
+	world[ant.Y][ant.X] = colours.Value(next.colour)
+	switch next.turn {
+	case turnRight:
+		ant.Direction = (ant.Direction + 1) % 4
+	case uTurn:
+		ant.Direction = (ant.Direction + 2) % 4
+	case turnLeft:
+		ant.Direction = (ant.Direction + 3) % 4
+	}
+	ant.State = next.state
+	switch ant.Direction {
+	case North:
+		ant.Y = (ant.Y - 1 + height) % height
+	case East:
+		ant.X = (ant.X + 1) % width
+	case South:
+		ant.Y = (ant.Y + 1) % height
+	case West:
+		ant.X = (ant.X - 1 + width) % width
+	}
+	return ant
+}
+
+// antWorker moves the ants of one strip, given by their indices, in the order of their index so that
+// ants on the same cell always act one after the other in the same order. Only the rows of the strip are
+// written, each copied before its first change so that the world of the previous turn stays as it was.
+func antWorker(t Turmite, ants []Ant, indices []int, before, after [][]uint8, turn int, colours Rule, c distributorChannels, done func()) {
+	height, width := len(before), len(before[0])
+	touched := make(map[util.Cell]bool)
+	for _, i := range indices {
+		y := ants[i].Y
+		if &after[y][0] == &before[y][0] {
+			after[y] = append([]uint8(nil), before[y]...)
+		}
+		touched[ants[i].Cell()] = true
+		ants[i] = t.move(ants[i], after, width, height, colours)
+	}
+	for cell := range touched {
+		if before[cell.Y][cell.X] != after[cell.Y][cell.X] {
+			c.cellChanged(turn, cell, before[cell.Y][cell.X], after[cell.Y][cell.X], colours)
+		}
+	}
+	done()
+}
+
+// moveAnts runs one turn of the turmite with the board split into strips between threads like the cells of
+// a rule. Every ant acts on the cell it is on, which is in exactly one strip, so the ants of different strips
+// never touch the same cell, and ants crossing into another strip are handed over for the next turn.
+func (t Turmite) moveAnts(ants []Ant, world [][]uint8, threads, turn int, colours Rule, c distributorChannels) [][]uint8 {
+	height := len(world)
+	next := append([][]uint8(nil), world...)
+	strips := make([][]int, threads)
+	//strip gives the strip of every row, split the same way as the cells of a rule
+	strip := make([]int, height)
+	startY := 0
+	for thread := 0; thread < threads; thread++ {
+		rows := height / threads
+		if thread < height%threads {
+			rows++
+		}
+		for y := startY; y < startY+rows; y++ {
+			strip[y] = thread
+		}
+		startY += rows
+	}
+	for i, ant := range ants {
+		strips[strip[ant.Y]] = append(strips[strip[ant.Y]], i)
+	}
+	done, wait := c.join(len(strips))
+	for _, indices := range strips {
+		go antWorker(t, ants, indices, world, next, turn, colours, c, done)
+	}
+	wait()
+	return next
+}
+
+// startAnts gives the ants of p, or a single ant in the centre of the board facing north if there are none.
+func startAnts(p Params, t Turmite) ([]Ant, error) {
+	if len(p.Ants) == 0 {
+		return []Ant{{X: p.ImageWidth / 2, Y: p.ImageHeight / 2, Direction: North}}, nil
+	}
+	ants := append([]Ant(nil), p.Ants...)
+	for _, ant := range ants {
+		switch {
+		case ant.X < 0 || ant.Y < 0 || ant.X >= p.ImageWidth || ant.Y >= p.ImageHeight:
+			return nil, fmt.Errorf("ant at (%d, %d) is outside the %dx%d board", ant.X, ant.Y, p.ImageWidth, p.ImageHeight)
+		case ant.Direction < North || ant.Direction > West:
+			return nil, fmt.Errorf("ant at (%d, %d) faces direction %d, which is not one of the 4", ant.X, ant.Y, ant.Direction)
+		case ant.State < 0 || ant.State >= len(t.table):
+			return nil, fmt.Errorf("ant at (%d, %d) is in state %d, which turmite %v does not have", ant.X, ant.Y, ant.State, t)
+		}
+	}
+	return ants, nil
+}
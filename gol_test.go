@@ -32,6 +32,7 @@ func TestGol(t *testing.T) {
 				t.Run(testName, func(t *testing.T) {
 
 					events := make(chan gol.Event)
+					p.OutDir = testOut
 					go gol.Run(p, events, nil)
 
 					var cells []util.Cell
@@ -87,6 +88,15 @@ func assertEqualBoard(t *testing.T, given, expected []util.Cell, p gol.Params) b
 	return true
 }
 
+// testOut is the directory the runs of the tests output their PGM files to, see gol.Params.OutDir, so that
+// the tests leave out/ as it is. TestMain makes it and removes it afterwards.
+var testOut string
+
+// outImage gives the path of a PGM file output by a run of the tests, named like ImageOutputComplete.Filename.
+func outImage(filename string) string {
+	return gol.Params{OutDir: testOut}.ImagePath(filename)
+}
+
 func readAliveCells(path string, width, height int) []util.Cell {
 	data, ioError := ioutil.ReadFile(path)
 	util.Check(ioError)
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestGrid runs rules on hexagonal and von Neumann grids against a simple reference implementation,
+// and checks the image of a hexagonal board with its odd rows offset.
+func TestGrid(t *testing.T) {
+	rulestrings := map[string]string{
+		"B2/S34H":  "B2/S34H",
+		"b2/s013v": "B2/S013V",
+		"34/2/3H":  "B2/S34/C3H",
+		"B245/S3H": "B245/S3H",
+		"B1/S012V": "B1/S012V",
+		"B3/S23":   "B3/S23",
+	}
+	for rulestring, expected := range rulestrings {
+		rule, err := gol.ParseRule(rulestring)
+		if err != nil || rule.String() != expected {
+			t.Errorf("ParseRule(%q) gave %v, %v, expected %v", rulestring, rule, err, expected)
+		}
+	}
+	for _, rulestring := range []string{"B7/S23H", "B2/S5V", "B2a/S3H", "B2/S3X"} {
+		if _, err := gol.ParseRule(rulestring); err == nil {
+			t.Errorf("ParseRule(%q) should fail", rulestring)
+		}
+	}
+
+	for _, rulestring := range []string{"B2/S34H", "B245/S3H", "B2/S013V", "B13/S012V"} {
+		for _, threads := range []int{1, 5, 16} {
+			p := gol.Params{Turns: 30, Threads: threads, ImageWidth: 64, ImageHeight: 64, Rule: rulestring,
+				Generator: gol.Generator{Kind: "random", Seed: 9, Density: 0.3}}
+			t.Run(fmt.Sprintf("%v-%d", rulestring, threads), func(t *testing.T) {
+				_, alive := runGenerated(p)
+				expected := referenceGrid(rulestring, states0(p), p.Turns)
+				var expectedAlive []util.Cell
+				for y := range expected {
+					for x, state := range expected[y] {
+						if state == 1 {
+							expectedAlive = append(expectedAlive, util.Cell{X: x, Y: y})
+						}
+					}
+				}
+				assertEqualBoard(t, alive, expectedAlive, p)
+			})
+		}
+	}
+
+	t.Run("image", func(t *testing.T) {
+		p := gol.Params{Turns: 3, Threads: 4, ImageWidth: 64, ImageHeight: 64, Rule: "B2/S34H",
+			Generator: gol.Generator{Kind: "random", Seed: 9, Density: 0.3}}
+		_, alive := runGenerated(p)
+		data, err := ioutil.ReadFile(outImage("64x64x3_hex"))
+		util.Check(err)
+		fields := strings.Fields(string(data))
+		if fields[1] != "129" || fields[2] != "64" {
+			t.Fatalf("Hexagonal image is %sx%s, expected 129x64", fields[1], fields[2])
+		}
+		pixels := data[len(data)-129*64:]
+		cells := make(map[util.Cell]bool)
+		for _, cell := range alive {
+			cells[cell] = true
+		}
+		for y := 0; y < 64; y++ {
+			for x := 0; x < 64; x++ {
+				left, right := pixels[y*129+2*x+y%2], pixels[y*129+2*x+y%2+1]
+				if (left == 0xFF) != cells[util.Cell{X: x, Y: y}] || left != right {
+					t.Fatalf("Cell (%d, %d) is drawn as %d, %d in the hexagonal image", x, y, left, right)
+				}
+			}
+		}
+	})
+}
+
+// referenceGrid runs a rule on a hexagonal or von Neumann grid the simple way.
+func referenceGrid(rulestring string, states [][]int, turns int) [][]int {
+	rule, _ := gol.ParseRule(rulestring)
+	height, width := len(states), len(states[0])
+	for turn := 0; turn < turns; turn++ {
+		next := make([][]int, height)
+		for y := range states {
+			next[y] = make([]int, width)
+			//von Neumann neighbours, and for odd rows of hexagonal boards the cells up and down and to the right
+			offsets := [][2]int{{0, -1}, {-1, 0}, {1, 0}, {0, 1}}
+			if rule.Neighbourhood == gol.Hexagonal {
+				side := -1
+				if y%2 == 1 {
+					side = 1
+				}
+				offsets = append(offsets, [2]int{side, -1}, [2]int{side, 1})
+			}
+			for x, state := range states[y] {
+				neighbours := 0
+				for _, offset := range offsets {
+					neighbours += states[(y+offset[1]+height)%height][(x+offset[0]+width)%width]
+				}
+				if state == 0 && rule.Birth[neighbours] || state == 1 && rule.Survive[neighbours] {
+					next[y][x] = 1
+				}
+			}
+		}
+		states = next
+	}
+	return states
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestHensel checks rulestrings in Hensel notation, that every letter picks the right configurations of two
+// neighbours, and that a rule listing every letter of Conway's Game of Life runs exactly like it.
+func TestHensel(t *testing.T) {
+	rulestrings := map[string]string{
+		"B2-a/S12":                          "B2-a/S12",
+		"b2-a/s12":                          "B2-a/S12",
+		"B3ceaiknjqry/S2ceaikn3ceaiknjqry":  "B3/S23",
+		"B3aceiknjqry/S2-a2a3":              "B3/S23",
+		"B2ci3-ck/S1e2kn3":                  "B2ci3-ck/S1e2kn3",
+		"B34ek5-iy/S23-a4itz/C4":            "B34ek5-iy/S23-a4itz/C4",
+		"B2ce3ceaiknjqry4ceaiknjqrytwz/S08": "B2ce34/S08",
+	}
+	for rulestring, expected := range rulestrings {
+		rule, err := gol.ParseRule(rulestring)
+		if err != nil || rule.String() != expected {
+			t.Errorf("ParseRule(%q) gave %v, %v, expected %v", rulestring, rule, err, expected)
+		}
+	}
+	for _, rulestring := range []string{"B2x/S23", "B1k/S23", "B0c/S23", "B2-/S23", "B3/S9"} {
+		if _, err := gol.ParseRule(rulestring); err == nil {
+			t.Errorf("ParseRule(%q) should fail", rulestring)
+		}
+	}
+
+	//a pattern, and the cells born from it in one turn under B2<letter>/S
+	births := []struct {
+		letter  string
+		pattern string
+		born    []util.Cell
+	}{
+		{"a", "oo", []util.Cell{{X: 4, Y: 3}, {X: 5, Y: 3}, {X: 4, Y: 5}, {X: 5, Y: 5}}},
+		{"-a", "oo", nil},
+		{"e", ".o\no.", []util.Cell{{X: 4, Y: 4}, {X: 5, Y: 5}}},
+		{"c", "o.o", []util.Cell{{X: 5, Y: 3}, {X: 5, Y: 5}}},
+		{"i", "o.o", []util.Cell{{X: 5, Y: 4}}},
+		{"k", "o..\n..o", []util.Cell{{X: 5, Y: 4}, {X: 5, Y: 5}}},
+		{"n", "o..\n...\n..o", []util.Cell{{X: 5, Y: 5}}},
+	}
+	for _, test := range births {
+		t.Run("B2"+test.letter, func(t *testing.T) {
+			f, err := ioutil.TempFile("", "hensel*.cells")
+			util.Check(err)
+			defer os.Remove(f.Name())
+			_, err = f.WriteString(test.pattern + "\n")
+			util.Check(err)
+			util.Check(f.Close())
+
+			p := gol.Params{ImageWidth: 16, ImageHeight: 16, Threads: 2, Turns: 1, Rule: "B2" + test.letter + "/S",
+				Generator: gol.Generator{Kind: "pattern", Pattern: f.Name(), X: 4, Y: 4}}
+			_, alive := runGenerated(p)
+			assertEqualBoard(t, alive, test.born, p)
+		})
+	}
+
+	for _, threads := range []int{1, 5, 16} {
+		p := gol.Params{Turns: 100, Threads: threads, ImageWidth: 64, ImageHeight: 64,
+			Rule: "B3ceaiknjqry/S2-a2a3-cey3cey"}
+		t.Run(fmt.Sprintf("conway-%d", threads), func(t *testing.T) {
+			events := make(chan gol.Event)
+			p.OutDir = testOut
+			go gol.Run(p, events, nil)
+			var final gol.FinalTurnComplete
+			for event := range events {
+				if e, ok := event.(gol.FinalTurnComplete); ok {
+					final = e
+				}
+			}
+			assertEqualBoard(t, final.Alive, readAliveCells("check/images/64x64x100.pgm", 64, 64), p)
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sync"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/sdl"
+	"uk.ac.bris.cs/gameoflife/term"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// record writes every event of a run of p and every key press of its user to a journal at path, see
+// gol.JournalWriter. The events of the run are passed on to the events it gives, and the key presses sent to
+// the keys it gives are passed on to keyPresses. The journal is complete once the events it gives are closed.
+func record(path string, p gol.Params, events <-chan gol.Event, keyPresses chan<- rune) (chan gol.Event, chan rune) {
+	file, err := os.Create(path)
+	util.Check(err)
+	journal, err := gol.NewJournalWriter(file, p)
+	util.Check(err)
+	recorded := make(chan gol.Event, cap(events))
+	keys := make(chan rune, cap(keyPresses))
+	//events and keys are passed on by their own goroutines, as the run can be waiting to send an event while
+	//its keys are full, and the mutex keeps their records whole
+	var mutex sync.Mutex
+	done := make(chan bool)
+	go func() {
+		for event := range events {
+			mutex.Lock()
+			util.Check(journal.Event(event))
+			mutex.Unlock()
+			recorded <- event
+		}
+		mutex.Lock()
+		util.Check(journal.Flush())
+		util.Check(file.Close())
+		close(done)
+		mutex.Unlock()
+		close(recorded)
+	}()
+	go func() {
+		for {
+			select {
+			case key := <-keys:
+				mutex.Lock()
+				select {
+				case <-done:
+					//the run has ended, and the journal with it
+					mutex.Unlock()
+					return
+				default:
+				}
+				util.Check(journal.Key(key))
+				mutex.Unlock()
+				select {
+				case keyPresses <- key:
+				case <-done:
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return recorded, keys
+}
+
+// replay is the function called when playing a journal back with 'go run . replay'
+func replay(args []string) {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+
+	speed := flags.Float64(
+		"speed",
+		1,
+		"Specify how many times faster than it was recorded the journal is played, or 0 for as fast as it can be shown. Defaults to 1.")
+
+	seek := flags.Int(
+		"seek",
+		0,
+		"Specify the turn to start playing from. The turns before it are shown straight away. Defaults to 0.")
+
+	useTerminal := flags.Bool(
+		"term",
+		false,
+		"Shows the board in the terminal instead of the SDL window.")
+
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: go run . replay [flags] journal")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	util.Check(err)
+	defer file.Close()
+	journal, err := gol.NewJournalReader(file)
+	util.Check(err)
+	p := journal.Params
+	fmt.Println("Width:", p.ImageWidth)
+	fmt.Println("Height:", p.ImageHeight)
+	fmt.Println("Speed:", *speed)
+
+	keyPresses := make(chan rune, 10)
+	events := make(chan gol.Event, 1000)
+	go playJournal(journal, *speed, *seek, events, keyPresses)
+	if *useTerminal {
+		term.Run(p, events, keyPresses)
+	} else {
+		sdl.Run(p, events, keyPresses, nil)
+	}
+	for range events {
+	}
+}
+
+// playJournal sends the events of a journal to events at speed times the pace they were recorded at, or as
+// fast as they are taken if speed is 0, and prints the key presses that were recorded when they come up.
+// Everything up to the TurnComplete of turn seek is sent straight away. Pressing p pauses and q stops the
+// playing, and events is closed at the end.
+func playJournal(journal *gol.JournalReader, speed float64, seek int, events chan<- gol.Event, keyPresses <-chan rune) {
+	defer close(events)
+	//the entries are played at the time start plus their time after offset, divided by speed
+	start := time.Now()
+	var offset time.Duration
+	seeking := seek > 0
+	paused := false
+	var pausedAt time.Time
+	turn := 0
+	//handle acts on a key press, and tells whether to stop
+	handle := func(key rune) bool {
+		switch key {
+		case 'p':
+			paused = !paused
+			if paused {
+				pausedAt = time.Now()
+				events <- gol.StateChange{CompletedTurns: turn, NewState: gol.Paused}
+			} else {
+				start = start.Add(time.Since(pausedAt))
+				events <- gol.StateChange{CompletedTurns: turn, NewState: gol.Executing}
+			}
+		case 'q':
+			return true
+		default:
+			fmt.Println("Only p and q work while replaying")
+		}
+		return false
+	}
+
+	for {
+		entry, err := journal.Next()
+		if err == io.EOF {
+			return
+		}
+		util.Check(err)
+		for {
+			wait := time.Duration(0)
+			if !seeking && speed > 0 {
+				//a year at most, as slow speeds could take the time past what a time.Duration holds
+				due := math.Min(float64(entry.Time-offset)/speed, float64(365*24*time.Hour))
+				wait = time.Until(start.Add(time.Duration(due)))
+			}
+			if !paused && wait <= 0 {
+				select {
+				case key := <-keyPresses:
+					if handle(key) {
+						return
+					}
+					continue
+				default:
+				}
+				break
+			}
+			var timeout <-chan time.Time
+			if !paused {
+				timeout = time.After(wait)
+			}
+			select {
+			case key := <-keyPresses:
+				if handle(key) {
+					return
+				}
+			case <-timeout:
+			}
+		}
+
+		if entry.Event == nil {
+			fmt.Printf("Recorded key press %q at %v\n", entry.Key, entry.Time)
+			continue
+		}
+		events <- entry.Event
+		if e, ok := entry.Event.(gol.TurnComplete); ok {
+			turn = e.CompletedTurns
+			if seeking && turn >= seek {
+				seeking = false
+				start, offset = time.Now(), entry.Time
+			}
+		}
+	}
+}
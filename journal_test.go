@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestJournal records a run with key presses to a journal and checks that reading it back gives the same
+// events and keys, that every kind of event survives the journal, and that playing it back with seeking
+// gives the events up to the turn sought straight away and stops on q.
+func TestJournal(t *testing.T) {
+	p := gol.Params{Turns: 100000000, Threads: 4, ImageWidth: 64, ImageHeight: 64}
+	dir, err := ioutil.TempDir("", "journal")
+	util.Check(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "run.golj")
+	t.Run("record", func(t *testing.T) {
+		engineEvents := make(chan gol.Event, 1000)
+		engineKeys := make(chan rune, 10)
+		p.OutDir = testOut
+		go gol.Run(p, engineEvents, engineKeys)
+		events, keyPresses := record(path, p, engineEvents, engineKeys)
+		var forwarded []gol.Event
+		keys := []rune{'p', 'p', 'q'}
+		for event := range events {
+			forwarded = append(forwarded, event)
+			if e, ok := event.(gol.TurnComplete); ok && e.CompletedTurns == 50 {
+				for _, key := range keys {
+					keyPresses <- key
+				}
+			}
+		}
+
+		file, err := os.Open(path)
+		util.Check(err)
+		defer file.Close()
+		journal, err := gol.NewJournalReader(file)
+		util.Check(err)
+		if !reflect.DeepEqual(journal.Params, p) {
+			t.Errorf("Expected the params of the run %v, got %v", p, journal.Params)
+		}
+		var read []gol.Event
+		var readKeys []rune
+		var last time.Duration
+		for {
+			entry, err := journal.Next()
+			if err == io.EOF {
+				break
+			}
+			util.Check(err)
+			if entry.Time < last {
+				t.Fatalf("Entry at %v comes after one at %v", entry.Time, last)
+			}
+			last = entry.Time
+			if entry.Event == nil {
+				readKeys = append(readKeys, entry.Key)
+			} else {
+				read = append(read, entry.Event)
+			}
+		}
+		if !reflect.DeepEqual(read, forwarded) {
+			t.Errorf("The %d events read back differ from the %d events of the run", len(read), len(forwarded))
+		}
+		if !reflect.DeepEqual(readKeys, keys) {
+			t.Errorf("Expected key presses %q, got %q", keys, readKeys)
+		}
+	})
+
+	t.Run("blocked keys", func(t *testing.T) {
+		//a run that is busy sending events does not take keys, which must not hold its events up
+		engineEvents := make(chan gol.Event)
+		engineKeys := make(chan rune)
+		events, keyPresses := record(filepath.Join(dir, "blocked.golj"), p, engineEvents, engineKeys)
+		keyPresses <- 'p'
+		sent := make(chan bool)
+		go func() {
+			for turn := 1; turn <= 3; turn++ {
+				engineEvents <- gol.TurnComplete{CompletedTurns: turn}
+			}
+			close(engineEvents)
+			sent <- true
+		}()
+		timeout := time.After(5 * time.Second)
+		for turn := 1; turn <= 3; turn++ {
+			select {
+			case event := <-events:
+				if event != (gol.TurnComplete{CompletedTurns: turn}) {
+					t.Errorf("Expected turn %d to be complete, got %v", turn, event)
+				}
+			case <-timeout:
+				t.Fatal("The events were held up by a key press the run did not take")
+			}
+		}
+		<-sent
+		for range events {
+		}
+	})
+
+	t.Run("events", func(t *testing.T) {
+		events := []gol.Event{
+			gol.CellFlipped{CompletedTurns: 0, Cell: util.Cell{X: 3, Y: 4}},
+			gol.CellFlipped{CompletedTurns: 0, Cell: util.Cell{X: -300, Y: -70000}},
+			gol.CellStateChanged{CompletedTurns: 0, Cell: util.Cell{X: 5, Y: 6}, State: 7},
+			gol.AntsMoved{CompletedTurns: 0, Ants: []gol.Ant{{X: 1, Y: 2, Direction: gol.Direction(3), State: 4}, {X: 9, Y: 8}}},
+			gol.TurnComplete{CompletedTurns: 1},
+			gol.TurnStats{CompletedTurns: 1, Population: 10, Births: 3, Deaths: 2, Min: util.Cell{X: -1, Y: 2},
+				Max: util.Cell{X: 30, Y: 40}, ActiveTiles: 5, Duration: 1234567},
+			gol.ThreadsChanged{CompletedTurns: 1, Threads: 6},
+			gol.AliveCellsCount{CompletedTurns: 900, CellsCount: 12345},
+			gol.StateChange{CompletedTurns: 900, NewState: gol.Paused},
+			gol.WorldEdited{CompletedTurns: 900, Flipped: 20},
+			gol.ImageOutputComplete{CompletedTurns: 900, Filename: "64x64x900"},
+			gol.FinalTurnComplete{CompletedTurns: 900, Alive: []util.Cell{{X: 1, Y: 1}, {X: 2, Y: 1}}},
+			gol.StateChange{CompletedTurns: 900, NewState: gol.Quitting},
+		}
+		var buffer bytes.Buffer
+		writer, err := gol.NewJournalWriter(&buffer, p)
+		util.Check(err)
+		for _, event := range events {
+			util.Check(writer.Event(event))
+		}
+		util.Check(writer.Flush())
+		reader, err := gol.NewJournalReader(&buffer)
+		util.Check(err)
+		for _, expected := range events {
+			entry, err := reader.Next()
+			if err != nil || !reflect.DeepEqual(entry.Event, expected) {
+				t.Errorf("Expected %#v, got %#v (%v)", expected, entry.Event, err)
+			}
+		}
+		if _, err := reader.Next(); err != io.EOF {
+			t.Errorf("Expected the end of the journal, got %v", err)
+		}
+	})
+
+	t.Run("replay", func(t *testing.T) {
+		file, err := os.Open(path)
+		util.Check(err)
+		defer file.Close()
+		journal, err := gol.NewJournalReader(file)
+		util.Check(err)
+		events := make(chan gol.Event)
+		keyPresses := make(chan rune, 1)
+		//so slow that nothing after the turn sought would be played during the test
+		go playJournal(journal, 1e-6, 30, events, keyPresses)
+		board := make(map[util.Cell]bool)
+		timeout := time.After(10 * time.Second)
+		for sought := false; !sought; {
+			select {
+			case event := <-events:
+				switch e := event.(type) {
+				case gol.CellFlipped:
+					board[e.Cell] = !board[e.Cell]
+				case gol.TurnComplete:
+					sought = e.CompletedTurns == 30
+				}
+			case <-timeout:
+				t.Fatal("The turn sought was not reached within 10s")
+			}
+		}
+		keyPresses <- 'q'
+		for range events {
+		}
+
+		var alive []util.Cell
+		for cell, on := range board {
+			if on {
+				alive = append(alive, cell)
+			}
+		}
+		p.Turns = 30
+		expected, _ := runTiled(p)
+		assertEqualBoard(t, alive, expected, p)
+	})
+}
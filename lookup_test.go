@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+)
+
+// TestLookup steps rules with tables of 4x4 blocks and checks that they give the same boards and
+// CellFlipped events as stepping cell by cell. The board has odd sides and is split between up to 16
+// threads, so that strips of a single row and left over rows and columns are covered.
+func TestLookup(t *testing.T) {
+	p := gol.Params{Turns: 100, Threads: 8, ImageWidth: 512, ImageHeight: 512, Lookup: true}
+	t.Run("512x512x100", func(t *testing.T) {
+		alive, flipped := runTiled(p)
+		expected := readAliveCells("check/images/512x512x100.pgm", p.ImageWidth, p.ImageHeight)
+		assertEqualBoard(t, alive, expected, p)
+		assertEqualBoard(t, flipped, expected, p)
+	})
+
+	random := gol.Generator{Kind: "random", Seed: 5, Density: 0.35}
+	for _, rulestring := range []string{"B3/S23", "B36/S23", "B2-a/S12", "B2/S013V", "B1357/S1357"} {
+		for _, threads := range []int{1, 4, 16} {
+			p := gol.Params{Turns: 40, Threads: threads, ImageWidth: 37, ImageHeight: 23, Rule: rulestring, Generator: random}
+			t.Run(fmt.Sprintf("%v-%d", rulestring, threads), func(t *testing.T) {
+				expectedAlive, expectedFlipped := runTiled(p)
+				p.Lookup = true
+				alive, flipped := runTiled(p)
+				assertEqualBoard(t, alive, expectedAlive, p)
+				assertEqualBoard(t, flipped, expectedFlipped, p)
+			})
+		}
+	}
+
+	t.Run("noise", func(t *testing.T) {
+		p := gol.Params{Turns: 40, Threads: 3, ImageWidth: 37, ImageHeight: 23, Generator: random,
+			Noise: gol.Noise{Birth: 0.9, Death: 0.02, Seed: 3}}
+		expected, _ := runTiled(p)
+		p.Lookup = true
+		alive, _ := runTiled(p)
+		assertEqualBoard(t, alive, expected, p)
+	})
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestLargerThanLife runs Larger than Life rules with every neighbourhood on a random 64x64 world
+// and checks the alive cells against a simple reference implementation.
+func TestLargerThanLife(t *testing.T) {
+	rulestrings := map[string]string{
+		"R5,C0,M1,S34..58,B34..45,NM":  "R5,C0,M1,S34..58,B34..45,NM", // Bosco's rule
+		"r2,c3,m0,s3-6,b4-5,nn":        "R2,C3,M0,S3..6,B4..5,NN",
+		"R3,C0,M0,S8..14,20,B9..12,NC": "R3,C0,M0,S8..14,20,B9..12,NC",
+	}
+	for rulestring, expected := range rulestrings {
+		rule, err := gol.ParseRule(rulestring)
+		if err != nil || rule.String() != expected {
+			t.Errorf("ParseRule(%q) gave %v, %v, expected %v", rulestring, rule, err, expected)
+		}
+	}
+	for _, rulestring := range []string{"R11,C0,M0,S1,B1,NM", "R2,C0,M2,S1,B1,NM", "R2,C0,M0,S1,B1,NX", "R2,C0,M0,S5..3,B1,NM",
+		"R5,C0,M1,S34..58,B34..45,N", "R5,C0,M1,S34..58,B34..45,NMN", "R,C0,M1,S34..58,B34..45,NM",
+		"R5,C,M1,S34..58,B34..45,NM", "R5,C0,M,S34..58,B34..45,NM"} {
+		if _, err := gol.ParseRule(rulestring); err == nil {
+			t.Errorf("ParseRule(%q) should fail", rulestring)
+		}
+	}
+
+	for rulestring := range rulestrings {
+		for _, threads := range []int{1, 5, 16} {
+			p := gol.Params{Turns: 10, Threads: threads, ImageWidth: 64, ImageHeight: 64, Rule: rulestring,
+				Generator: gol.Generator{Kind: "random", Seed: 3, Density: 0.4}}
+			t.Run(fmt.Sprintf("%v-%d", rulestring, threads), func(t *testing.T) {
+				rule, _ := gol.ParseRule(p.Rule)
+				//with no turns only the cells of the initial world are flipped
+				initial, _ := runGenerated(gol.Params{ImageWidth: 64, ImageHeight: 64, Rule: p.Rule, Generator: p.Generator, Threads: 1})
+				_, alive := runGenerated(p)
+				assertEqualBoard(t, alive, referenceLargerThanLife(rule, initial, p), p)
+			})
+		}
+	}
+
+	//the smallest board a rule of range 5 runs on, where the neighbourhood of every cell spans the whole board
+	p := gol.Params{Turns: 10, Threads: 4, ImageWidth: 11, ImageHeight: 11, Rule: "R5,C0,M1,S34..58,B34..45,NM",
+		Generator: gol.Generator{Kind: "random", Seed: 3, Density: 0.4}}
+	t.Run(fmt.Sprintf("%v-%dx%d", p.Rule, p.ImageWidth, p.ImageHeight), func(t *testing.T) {
+		rule, _ := gol.ParseRule(p.Rule)
+		initial, _ := runGenerated(gol.Params{ImageWidth: 11, ImageHeight: 11, Rule: p.Rule, Generator: p.Generator, Threads: 1})
+		_, alive := runGenerated(p)
+		assertEqualBoard(t, alive, referenceLargerThanLife(rule, initial, p), p)
+	})
+}
+
+// referenceLargerThanLife runs a Larger than Life rule the simple way, looking at every cell of every neighbourhood.
+func referenceLargerThanLife(rule gol.Rule, initial []util.Cell, p gol.Params) []util.Cell {
+	width, height := p.ImageWidth, p.ImageHeight
+	states := make([][]int, height)
+	for y := range states {
+		states[y] = make([]int, width)
+	}
+	for _, cell := range initial {
+		states[cell.Y][cell.X] = 1
+	}
+	r := rule.Range
+	inside := func(dx, dy int) bool {
+		switch rule.Neighbourhood {
+		case gol.VonNeumann:
+			return abs(dx)+abs(dy) <= r
+		case gol.Circular:
+			return dx*dx+dy*dy <= r*r+r
+		default:
+			return true
+		}
+	}
+	within := func(n int, intervals []gol.Interval) bool {
+		for _, interval := range intervals {
+			if n >= interval.Min && n <= interval.Max {
+				return true
+			}
+		}
+		return false
+	}
+	for turn := 0; turn < p.Turns; turn++ {
+		next := make([][]int, height)
+		for y := range states {
+			next[y] = make([]int, width)
+			for x, state := range states[y] {
+				n := 0
+				for dy := -r; dy <= r; dy++ {
+					for dx := -r; dx <= r; dx++ {
+						if (dx != 0 || dy != 0 || rule.Middle) && inside(dx, dy) &&
+							states[(y+dy+height)%height][(x+dx+width)%width] == 1 {
+							n++
+						}
+					}
+				}
+				switch {
+				case state == 0 && within(n, rule.BirthIntervals):
+					next[y][x] = 1
+				case state == 0:
+				case state == 1 && within(n, rule.SurviveIntervals):
+					next[y][x] = 1
+				default:
+					next[y][x] = (state + 1) % rule.States
+				}
+			}
+		}
+		states = next
+	}
+	var alive []util.Cell
+	for y := range states {
+		for x, state := range states[y] {
+			if state == 1 {
+				alive = append(alive, util.Cell{X: x, Y: y})
+			}
+		}
+	}
+	return alive
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
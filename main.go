@@ -3,14 +3,26 @@ package main
 import (
 	"flag"
 	"fmt"
+	"os"
 	"runtime"
 
 	"uk.ac.bris.cs/gameoflife/gol"
 	"uk.ac.bris.cs/gameoflife/sdl"
+	"uk.ac.bris.cs/gameoflife/term"
+	"uk.ac.bris.cs/gameoflife/web"
 )
 
 // main is the function called when starting Game of Life with 'go run .'
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "search" {
+		search(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		runtime.LockOSThread()
+		replay(os.Args[2:])
+		return
+	}
 	runtime.LockOSThread()
 	var params gol.Params
 
@@ -18,7 +30,7 @@ func main() {
 		&params.Threads,
 		"t",
 		8,
-		"Specify the number of worker threads to use. Defaults to 8.")
+		"Specify the number of worker threads to use, or 0 to pick the fastest number while running. Defaults to 8.")
 
 	flag.IntVar(
 		&params.ImageWidth,
@@ -38,23 +50,188 @@ func main() {
 		10000000000,
 		"Specify the number of turns to process. Defaults to 10000000000.")
 
+	flag.StringVar(
+		&params.Rule,
+		"rule",
+		"B3/S23",
+		"Specify the rule in B/S notation, Hensel notation for isotropic rules like B2-a/S12, a final H or V for hexagonal or von Neumann grids like B2/S34H, B/S/C for Generations rules like B2/S/C3, Larger than Life notation like R5,C0,M1,S34..58,B34..45,NM, Margolus notation like MS,D0;8;4;3;2;5;9;7;1;6;10;11;12;13;14;15, or a name like HighLife, Wireworld or Critters. Defaults to B3/S23, Conway's Game of Life.")
+
+	flag.StringVar(
+		&params.Generator.Kind,
+		"gen",
+		"",
+		"Make up the initial world instead of reading it from images/: random, box, C2, C4, D8 or pattern.")
+
+	flag.Int64Var(
+		&params.Generator.Seed,
+		"seed",
+		1,
+		"Specify the seed of a generated world. Defaults to 1.")
+
+	flag.Float64Var(
+		&params.Generator.Density,
+		"density",
+		0.5,
+		"Specify the chance of each generated cell being alive. Defaults to 0.5.")
+
+	flag.IntVar(
+		&params.Generator.Size,
+		"size",
+		0,
+		"Specify the side of the generated square for box, C2, C4 and D8. Defaults to the shorter side of the board.")
+
+	flag.StringVar(
+		&params.Generator.Pattern,
+		"pattern",
+		"",
+		"Specify the .rle, .mcl, .cells or .pgm file to stamp with -gen pattern, or to paste with v in the SDL window.")
+
+	flag.IntVar(
+		&params.Generator.X,
+		"x",
+		-1,
+		"Specify the column of the top-left corner of the stamped pattern. Defaults to centring it.")
+
+	flag.IntVar(
+		&params.Generator.Y,
+		"y",
+		-1,
+		"Specify the row of the top-left corner of the stamped pattern. Defaults to centring it.")
+
+	flag.StringVar(
+		&params.StatsFile,
+		"stats",
+		"",
+		"Write population, births, deaths, bounding box, active tiles and turn time of every turn to this .csv or .jsonl file.")
+
+	flag.Float64Var(
+		&params.Noise.Birth,
+		"pbirth",
+		1,
+		"Specify the chance of a cell the rule makes alive actually being born. Defaults to 1.")
+
+	flag.Float64Var(
+		&params.Noise.Death,
+		"pdeath",
+		0,
+		"Specify the chance of an alive cell the rule keeps alive dying anyway. Defaults to 0.")
+
+	flag.Int64Var(
+		&params.Noise.Seed,
+		"noiseseed",
+		1,
+		"Specify the seed of the random births and deaths of -pbirth and -pdeath. Defaults to 1.")
+
+	flag.StringVar(
+		&params.Turmite,
+		"turmite",
+		"",
+		"Run ants instead of the rule, with turns like RL for Langton's ant or a Golly turmite table like {{{1,2,0},{0,8,0}}}.")
+
+	ants := flag.Int(
+		"ants",
+		1,
+		"Specify the number of ants of -turmite, spread along the diagonal of the board. Defaults to 1, in the centre.")
+
+	flag.BoolVar(
+		&params.Reverse,
+		"reverse",
+		false,
+		"Run a reversible Margolus rule backwards, undoing -turns turns of a forward run that ended with the loaded world. Press b to turn around while running.")
+
+	flag.BoolVar(
+		&params.FullRecompute,
+		"fullrecompute",
+		false,
+		"Compute every cell in every turn, instead of only the parts of the board that are still changing.")
+
+	flag.IntVar(
+		&params.TileWidth,
+		"tilewidth",
+		0,
+		"Split the board into tiles this wide that the workers take from a queue, instead of strips. 0 spans the whole width. Cannot be used with turmites or Margolus rules.")
+
+	flag.IntVar(
+		&params.TileHeight,
+		"tileheight",
+		0,
+		"Split the board into tiles this high that the workers take from a queue, instead of strips. 0 spans the whole height. Cannot be used with turmites or Margolus rules.")
+
+	flag.BoolVar(
+		&params.Lookup,
+		"lookup",
+		false,
+		"Step rules with two states 2x2 cells at a time, with a table of the next states of every 4x4 block.")
+
+	flag.StringVar(
+		&params.Engine,
+		"engine",
+		"channels",
+		"Specify how the goroutines communicate: channels, or shared for shared memory with mutexes and condition variables. Defaults to channels.")
+
+	flag.BoolVar(
+		&params.Unbounded,
+		"unbounded",
+		false,
+		"Run on a plane without edges that grows as the cells reach further, instead of a board that wraps around. The window follows the centre of the cells, or press o to stop following and pan with the arrow keys.")
+
+	journal := flag.String(
+		"journal",
+		"",
+		"Record every event and key press of the run to this journal, to be played back with 'go run . replay'.")
+
 	noVis := flag.Bool(
 		"noVis",
 		false,
-		"Disables the SDL window, so there is no visualisation during the tests.")
+		"Disables the SDL window, so there is no visualisation during the tests and no event is made for every cell that flips.")
+
+	useTerminal := flag.Bool(
+		"term",
+		false,
+		"Shows the board in the terminal instead of the SDL window.")
+
+	httpAddr := flag.String(
+		"http",
+		"",
+		"Serve a live view in the browser on this address, e.g. localhost:8080, instead of the SDL window.")
 
 	flag.Parse()
 
+	if params.Turmite != "" && *ants > 1 {
+		for i := 1; i <= *ants; i++ {
+			params.Ants = append(params.Ants, gol.Ant{
+				X: i * params.ImageWidth / (*ants + 1),
+				Y: i * params.ImageHeight / (*ants + 1),
+			})
+		}
+	}
+
 	fmt.Println("Threads:", params.Threads)
 	fmt.Println("Width:", params.ImageWidth)
 	fmt.Println("Height:", params.ImageHeight)
 
 	keyPresses := make(chan rune, 10)
-	events := make(chan gol.Event, 1000)
+	edits := make(chan gol.Edit)
 
-	go gol.Run(params, events, keyPresses)
-	if !(*noVis) {
-		sdl.Run(params, events, keyPresses)
+	bus := gol.NewBus()
+	var events <-chan gol.Event
+	if *noVis && *journal == "" {
+		//nothing shows the cells, so the engine does not make an event for every cell that flips
+		events = bus.Subscribe(1, gol.Block, gol.FinalTurnComplete{})
+	} else {
+		events = bus.Subscribe(1000, gol.Block)
+	}
+
+	go gol.RunWithBus(params, bus, keyPresses, edits)
+	if *journal != "" {
+		events, keyPresses = record(*journal, params, events, keyPresses)
+	}
+	if *useTerminal {
+		term.Run(params, events, keyPresses)
+	} else if *httpAddr != "" {
+		web.Run(params, events, keyPresses, *httpAddr)
+	} else if !(*noVis) {
+		sdl.Run(params, events, keyPresses, edits)
 	} else {
 		complete := false
 		for !complete {
@@ -65,4 +242,7 @@ func main() {
 			}
 		}
 	}
+	// Wait for the final image and stats to be written, the events channel is closed once they are.
+	for range events {
+	}
 }
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestMargolus runs Critters and the billiard-ball machine on 1-16 worker threads against a simple reference
+// implementation, and checks that running them backwards gives back the world they started from.
+func TestMargolus(t *testing.T) {
+	rulestrings := map[string]string{
+		"Critters":                               "MS,D15;14;13;3;11;5;6;1;7;9;10;2;12;4;8;0",
+		"BBM":                                    "MS,D0;8;4;3;2;5;9;7;1;6;10;11;12;13;14;15",
+		"m0,8,4,3,2,5,9,7,1,6,10,11,12,13,14,15": "MS,D0;8;4;3;2;5;9;7;1;6;10;11;12;13;14;15",
+	}
+	for rulestring, expected := range rulestrings {
+		rule, err := gol.ParseRule(rulestring)
+		if err != nil || rule.String() != expected {
+			t.Errorf("ParseRule(%q) gave %v, %v, expected %v", rulestring, rule, err, expected)
+		}
+	}
+	for _, rulestring := range []string{"MS,D0;8;4;3;2;5;9;7;1;6;10;11;12;13;14", "MS,D0;8;4;3;2;5;9;7;1;6;10;11;12;13;14;16"} {
+		if _, err := gol.ParseRule(rulestring); err == nil {
+			t.Errorf("ParseRule(%q) should fail", rulestring)
+		}
+	}
+
+	for _, rulestring := range []string{"Critters", "BBM"} {
+		for _, size := range []int{16, 64} {
+			for _, threads := range []int{1, 3, 8, 16} {
+				p := gol.Params{Turns: 51, Threads: threads, ImageWidth: size, ImageHeight: size, Rule: rulestring,
+					Generator: gol.Generator{Kind: "random", Seed: 2, Density: 0.25}}
+				t.Run(fmt.Sprintf("%v-%dx%d-%d", rulestring, size, size, threads), func(t *testing.T) {
+					rule, _ := gol.ParseRule(rulestring)
+					_, alive := runGenerated(p)
+					expected := referenceMargolus(rule.Margolus, states0(p), p.Turns)
+					var expectedAlive []util.Cell
+					for y := range expected {
+						for x, state := range expected[y] {
+							if state == 1 {
+								expectedAlive = append(expectedAlive, util.Cell{X: x, Y: y})
+							}
+						}
+					}
+					assertEqualBoard(t, alive, expectedAlive, p)
+				})
+			}
+		}
+
+		t.Run(rulestring+"-reverse", func(t *testing.T) {
+			p := gol.Params{Turns: 37, Threads: 5, ImageWidth: 64, ImageHeight: 64, Rule: rulestring,
+				Generator: gol.Generator{Kind: "random", Seed: 3, Density: 0.25}}
+			var initial []util.Cell
+			for y, row := range states0(p) {
+				for x, state := range row {
+					if state == 1 {
+						initial = append(initial, util.Cell{X: x, Y: y})
+					}
+				}
+			}
+			runGenerated(p)
+			data, err := ioutil.ReadFile(outImage("64x64x37"))
+			util.Check(err)
+			f, err := ioutil.TempFile("", "forward*.pgm")
+			util.Check(err)
+			defer os.Remove(f.Name())
+			_, err = f.Write(data)
+			util.Check(err)
+			util.Check(f.Close())
+
+			p.Reverse = true
+			p.Generator = gol.Generator{Kind: "pattern", Pattern: f.Name()}
+			_, alive := runGenerated(p)
+			assertEqualBoard(t, alive, initial, p)
+		})
+	}
+}
+
+// referenceMargolus runs a Margolus rule the simple way.
+func referenceMargolus(table []int, states [][]int, turns int) [][]int {
+	height, width := len(states), len(states[0])
+	for turn := 0; turn < turns; turn++ {
+		next := make([][]int, height)
+		for y := range next {
+			next[y] = make([]int, width)
+		}
+		for y := turn % 2; y < height+turn%2; y += 2 {
+			for x := turn % 2; x < width+turn%2; x += 2 {
+				//top-left, top-right, bottom-left, bottom-right
+				cells := [4][2]int{{x, y}, {x + 1, y}, {x, y + 1}, {x + 1, y + 1}}
+				block := 0
+				for i, cell := range cells {
+					block |= states[cell[1]%height][cell[0]%width] << uint(i)
+				}
+				for i, cell := range cells {
+					next[cell[1]%height][cell[0]%width] = table[block] >> uint(i) & 1
+				}
+			}
+		}
+		states = next
+	}
+	return states
+}
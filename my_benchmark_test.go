@@ -9,20 +9,112 @@ import (
 
 const benchLength = 1000
 
+// BenchmarkGol runs the channel engine, then the shared engine with the names ending in -shared.
 func BenchmarkGol(b *testing.B) {
-	for threads := 1; threads <= 16; threads++ {
-		os.Stdout = nil // Disable all program output apart from benchmark results
+	for _, engine := range []string{"channels", "shared"} {
+		for threads := 1; threads <= 16; threads++ {
+			os.Stdout = nil // Disable all program output apart from benchmark results
+			p := gol.Params{
+				Turns:       benchLength,
+				Threads:     threads,
+				ImageWidth:  512,
+				ImageHeight: 512,
+				Engine:      engine,
+			}
+			name := fmt.Sprintf("%dx%dx%d-%d", p.ImageWidth, p.ImageHeight, p.Turns, p.Threads)
+			if engine != "channels" {
+				name += "-" + engine
+			}
+			b.Run(name, func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					events := make(chan gol.Event)
+					p.OutDir = testOut
+					go gol.Run(p, events, nil)
+					for range events {
+
+					}
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkSparse runs a small soup in the middle of a large empty board, with and without skipping the
+// tiles that have settled.
+func BenchmarkSparse(b *testing.B) {
+	os.Stdout = nil
+	for _, full := range []bool{false, true} {
+		p := gol.Params{
+			Turns:         benchLength,
+			Threads:       8,
+			ImageWidth:    1024,
+			ImageHeight:   1024,
+			Generator:     gol.Generator{Kind: "box", Seed: 1, Density: 0.4, Size: 64},
+			FullRecompute: full,
+		}
+		b.Run(fmt.Sprintf("%dx%dx%d-full=%v", p.ImageWidth, p.ImageHeight, p.Turns, full), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				events := make(chan gol.Event)
+				p.OutDir = testOut
+				go gol.Run(p, events, nil)
+				for range events {
+
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkLookup compares stepping every cell on its own with stepping 2x2 cells at a time with a table of
+// 4x4 blocks. Both compute every cell in every turn, so that skipping settled tiles does not hide the difference.
+func BenchmarkLookup(b *testing.B) {
+	os.Stdout = nil
+	for _, threads := range []int{1, 8} {
+		for _, lookup := range []bool{false, true} {
+			p := gol.Params{
+				Turns:         benchLength,
+				Threads:       threads,
+				ImageWidth:    512,
+				ImageHeight:   512,
+				FullRecompute: true,
+				Lookup:        lookup,
+			}
+			b.Run(fmt.Sprintf("%dx%dx%d-%d-lookup=%v", p.ImageWidth, p.ImageHeight, p.Turns, p.Threads, lookup), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					events := make(chan gol.Event)
+					p.OutDir = testOut
+					go gol.Run(p, events, nil)
+					for range events {
+
+					}
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkQuiet compares a subscriber that takes every event with one that only takes FinalTurnComplete,
+// for which the engine does not make a CellFlipped event for every cell that flips, with the buffers of main.
+func BenchmarkQuiet(b *testing.B) {
+	os.Stdout = nil
+	for _, quiet := range []bool{false, true} {
 		p := gol.Params{
 			Turns:       benchLength,
-			Threads:     threads,
+			Threads:     8,
 			ImageWidth:  512,
 			ImageHeight: 512,
 		}
-		name := fmt.Sprintf("%dx%dx%d-%d", p.ImageWidth, p.ImageHeight, p.Turns, p.Threads)
-		b.Run(name, func(b *testing.B) {
+		b.Run(fmt.Sprintf("%dx%dx%d-%d-quiet=%v", p.ImageWidth, p.ImageHeight, p.Turns, p.Threads, quiet), func(b *testing.B) {
 			for i := 0; i < b.N; i++ {
-				events := make(chan gol.Event)
-				go gol.Run(p, events, nil)
+				bus := gol.NewBus()
+				var events <-chan gol.Event
+				if quiet {
+					events = bus.Subscribe(1, gol.Block, gol.FinalTurnComplete{})
+				} else {
+					events = bus.Subscribe(1000, gol.Block)
+				}
+				p.OutDir = testOut
+				go gol.RunWithBus(p, bus, nil, nil)
 				for range events {
 
 				}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestNoise checks that stochastic runs give the same board on 1-16 worker threads, that noise that changes
+// nothing gives Conway's Game of Life, and that births and deaths happen about as often as they should.
+func TestNoise(t *testing.T) {
+	p := gol.Params{ImageWidth: 64, ImageHeight: 64, Turns: 100, Noise: gol.Noise{Birth: 0.95, Death: 0.01, Seed: 7}}
+	var expectedAlive []util.Cell
+	for threads := 1; threads <= 16; threads++ {
+		p.Threads = threads
+		t.Run(fmt.Sprintf("%dx%dx%d-%d", p.ImageWidth, p.ImageHeight, p.Turns, p.Threads), func(t *testing.T) {
+			alive := runNoise(p)
+			if threads == 1 {
+				expectedAlive = alive
+				if len(alive) == 0 {
+					t.Fatal("Every cell died, the board cannot show differences between threads")
+				}
+				return
+			}
+			assertEqualBoard(t, alive, expectedAlive, p)
+		})
+	}
+
+	t.Run("seed", func(t *testing.T) {
+		p.Threads = 4
+		p.Noise.Seed = 8
+		if alive := runNoise(p); equalCells(alive, expectedAlive) {
+			t.Error("Another noise seed gave the same board")
+		}
+	})
+
+	t.Run("deterministic", func(t *testing.T) {
+		p := gol.Params{ImageWidth: 64, ImageHeight: 64, Turns: 100, Threads: 8, Noise: gol.Noise{Birth: 1, Seed: 7}}
+		assertEqualBoard(t, runNoise(p), readAliveCells("check/images/64x64x100.pgm", 64, 64), p)
+	})
+
+	//with every cell surviving, or every dead cell born, a single turn shows how often the noise acts
+	initial := len(readAliveCells("check/images/64x64x0.pgm", 64, 64))
+	rates := []struct {
+		rule     string
+		noise    gol.Noise
+		expected float64
+	}{
+		{"B/S012345678", gol.Noise{Death: 0.2, Seed: 3}, 0.8 * float64(initial)},
+		{"B012345678/S012345678", gol.Noise{Birth: 0.3, Seed: 3}, float64(initial) + 0.3*float64(64*64-initial)},
+	}
+	for _, rate := range rates {
+		t.Run(rate.rule, func(t *testing.T) {
+			p := gol.Params{ImageWidth: 64, ImageHeight: 64, Turns: 1, Threads: 3, Rule: rate.rule, Noise: rate.noise}
+			alive := float64(len(runNoise(p)))
+			//a binomial count is well within 5 standard deviations of its mean
+			if math.Abs(alive-rate.expected) > 5*math.Sqrt(rate.expected) {
+				t.Errorf("%v alive cells after one turn, expected about %.0f", alive, rate.expected)
+			}
+		})
+	}
+}
+
+func runNoise(p gol.Params) []util.Cell {
+	events := make(chan gol.Event)
+	p.OutDir = testOut
+	go gol.Run(p, events, nil)
+	var alive []util.Cell
+	for event := range events {
+		if e, ok := event.(gol.FinalTurnComplete); ok {
+			alive = e.Alive
+		}
+	}
+	return alive
+}
+
+func equalCells(a, b []util.Cell) bool {
+	cells := make(map[util.Cell]bool)
+	for _, cell := range a {
+		cells[cell] = true
+	}
+	if len(a) != len(b) {
+		return false
+	}
+	for _, cell := range b {
+		if !cells[cell] {
+			return false
+		}
+	}
+	return true
+}
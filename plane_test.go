@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestUnbounded runs soups on the unbounded plane, where the gliders they give off travel away instead of
+// wrapping around, and checks the final cells, the CellFlipped events and the PGM output of the bounds of
+// the plane against a reference that steps the cells of an unbounded set.
+func TestUnbounded(t *testing.T) {
+	random := gol.Generator{Kind: "random", Seed: 4, Density: 0.35}
+	runs := []gol.Params{
+		{Turns: 200, Threads: 1, Rule: "B3/S23"},
+		{Turns: 200, Threads: 3, Rule: "B3/S23"},
+		{Turns: 200, Threads: 8, Rule: "B3/S23", Engine: "shared"},
+		{Turns: 200, Threads: 4, Rule: "B3/S23", Lookup: true},
+		{Turns: 100, Threads: 4, Rule: "B36/S23"},
+		{Turns: 60, Threads: 4, Rule: "B2/S/C3"},
+	}
+	for _, p := range runs {
+		p.ImageWidth, p.ImageHeight, p.Generator, p.Unbounded = 40, 30, random, true
+		name := fmt.Sprintf("%v-%d-%v", p.Rule, p.Threads, p.Engine)
+		if p.Lookup {
+			name += "-lookup"
+		}
+		t.Run(name, func(t *testing.T) {
+			rule, err := gol.ParseRule(p.Rule)
+			util.Check(err)
+			expected, notDead := referencePlane(rule, states0(p), p.Turns)
+			alive, flipped, filename := runUnbounded(p)
+			assertEqualBoard(t, alive, expected, p)
+			assertEqualBoard(t, flipped, expected, p)
+
+			var width, height, turns, left, top int
+			_, err = fmt.Sscanf(filename, "%dx%dx%d_%d_%d", &width, &height, &turns, &left, &top)
+			if err != nil || turns != p.Turns {
+				t.Fatalf("Unexpected output image %v", filename)
+			}
+			var image []util.Cell
+			for _, cell := range readAliveCells(outImage(filename), width, height) {
+				image = append(image, util.Cell{X: cell.X + left, Y: cell.Y + top})
+			}
+			assertEqualBoard(t, image, notDead, p)
+
+			outside := false
+			for _, cell := range alive {
+				outside = outside || cell.X < 0 || cell.Y < 0 || cell.X >= p.ImageWidth || cell.Y >= p.ImageHeight
+			}
+			if !outside {
+				t.Errorf("Expected cells to have left the board")
+			}
+		})
+	}
+}
+
+// TestUnboundedRejected checks that rules that give birth to cells without alive neighbours are rejected on
+// the unbounded plane, where every one of its cells would be born, and that they still run on a board.
+func TestUnboundedRejected(t *testing.T) {
+	for _, rulestring := range []string{"B03/S23", "B0/S8", "B0/S/C3", "R2,C0,M0,S3..5,B0..2,NM"} {
+		p := gol.Params{Turns: 1, Threads: 1, ImageWidth: 16, ImageHeight: 16, Rule: rulestring}
+		if err := gol.Validate(p); err != nil {
+			t.Errorf("Expected rule %v to run on a board, got %v", rulestring, err)
+		}
+		p.Unbounded = true
+		if err := gol.Validate(p); err == nil {
+			t.Errorf("Expected rule %v to be rejected on the unbounded plane", rulestring)
+		}
+	}
+	p := gol.Params{Turns: 1, Threads: 1, ImageWidth: 16, ImageHeight: 16, Rule: "B36/S23", Unbounded: true}
+	if err := gol.Validate(p); err != nil {
+		t.Errorf("Expected rule %v to run on the unbounded plane, got %v", p.Rule, err)
+	}
+}
+
+// runUnbounded gives the final alive cells of a run, the alive cells rebuilt from its CellFlipped events and
+// the name of its last PGM output.
+func runUnbounded(p gol.Params) ([]util.Cell, []util.Cell, string) {
+	events := make(chan gol.Event)
+	p.OutDir = testOut
+	go gol.Run(p, events, nil)
+	board := make(map[util.Cell]bool)
+	var alive, flipped []util.Cell
+	filename := ""
+	for event := range events {
+		switch e := event.(type) {
+		case gol.CellFlipped:
+			board[e.Cell] = !board[e.Cell]
+		case gol.FinalTurnComplete:
+			alive = e.Alive
+		case gol.ImageOutputComplete:
+			filename = e.Filename
+		}
+	}
+	for cell, on := range board {
+		if on {
+			flipped = append(flipped, cell)
+		}
+	}
+	return alive, flipped, filename
+}
+
+// referencePlane runs a rule of range 1 the simple way, on a plane without edges kept as the states of the
+// cells that are not dead. It gives the alive cells and every cell that is not dead, the dying ones included.
+func referencePlane(rule gol.Rule, states [][]int, turns int) ([]util.Cell, []util.Cell) {
+	cells := make(map[util.Cell]int)
+	for y := range states {
+		for x, state := range states[y] {
+			if state != 0 {
+				cells[util.Cell{X: x, Y: y}] = state
+			}
+		}
+	}
+	count := rule.States
+	if count == 0 {
+		count = 2
+	}
+	for turn := 0; turn < turns; turn++ {
+		neighbours := make(map[util.Cell]int)
+		for cell, state := range cells {
+			if state != 1 {
+				continue
+			}
+			for j := -1; j <= 1; j++ {
+				for i := -1; i <= 1; i++ {
+					if i != 0 || j != 0 {
+						neighbours[util.Cell{X: cell.X + i, Y: cell.Y + j}]++
+					}
+				}
+			}
+		}
+		next := make(map[util.Cell]int)
+		for cell, n := range neighbours {
+			if cells[cell] == 0 && rule.Birth[n] {
+				next[cell] = 1
+			}
+		}
+		for cell, state := range cells {
+			switch {
+			case state == 1 && rule.Survive[neighbours[cell]]:
+				next[cell] = 1
+			case (state+1)%count != 0:
+				next[cell] = state + 1
+			}
+		}
+		cells = next
+	}
+	var alive, notDead []util.Cell
+	for cell, state := range cells {
+		if state == 1 {
+			alive = append(alive, cell)
+		}
+		notDead = append(notDead, cell)
+	}
+	return alive, notDead
+}
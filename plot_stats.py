@@ -0,0 +1,41 @@
+
+import sys
+import pandas as pd
+import matplotlib.pyplot as plt
+import seaborn as sns
+
+# Read in the statistics written with 'go run . -stats out/stats.csv' (or a .jsonl file).
+path = sys.argv[1] if len(sys.argv) > 1 else 'out/stats.csv'
+if path.endswith('.jsonl') or path.endswith('.json'):
+    stats = pd.read_json(path, lines=True)
+    # The bounding box is stored as two {"X": .., "Y": ..} objects in JSON Lines.
+    stats['min_x'] = stats['min'].str['X']
+    stats['min_y'] = stats['min'].str['Y']
+    stats['max_x'] = stats['max'].str['X']
+    stats['max_y'] = stats['max'].str['Y']
+else:
+    stats = pd.read_csv(path, header=0)
+
+# Go stores durations in nanoseconds. Convert them to milliseconds.
+stats['duration_ms'] = stats['duration_ns'] / 1e+6
+stats['box_area'] = (stats['max_x'] - stats['min_x'] + 1) * (stats['max_y'] - stats['min_y'] + 1)
+
+print(stats.describe())
+
+fig, axes = plt.subplots(4, 1, sharex=True)
+
+sns.lineplot(data=stats, x='completed_turns', y='population', ax=axes[0])
+axes[0].set(ylabel='Alive cells')
+
+sns.lineplot(data=stats, x='completed_turns', y='births', ax=axes[1], label='births')
+sns.lineplot(data=stats, x='completed_turns', y='deaths', ax=axes[1], label='deaths')
+axes[1].set(ylabel='Cells per turn')
+
+sns.lineplot(data=stats, x='completed_turns', y='active_tiles', ax=axes[2])
+axes[2].set(ylabel='Active tiles')
+
+sns.lineplot(data=stats, x='completed_turns', y='duration_ms', ax=axes[3])
+axes[3].set(xlabel='Completed turns', ylabel='Turn time (ms)')
+
+# Display the full figure.
+plt.show()
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+)
+
+// TestQuit presses q in the middle of a run on both engines and checks that the run ends like it reached its
+// last turn: FinalTurnComplete is sent for the turn it was quit at, the PGM file output is named after that
+// turn and holds the final board, and the events channel is closed after Quitting.
+func TestQuit(t *testing.T) {
+	for _, engine := range []string{"channels", "shared"} {
+		t.Run(engine, func(t *testing.T) {
+			p := gol.Params{Turns: 100000000, Threads: 4, ImageWidth: 64, ImageHeight: 64, Engine: engine}
+			events := make(chan gol.Event)
+			keyPresses := make(chan rune, 1)
+			p.OutDir = testOut
+			go gol.Run(p, events, keyPresses)
+			var final *gol.FinalTurnComplete
+			turn, filename, quitting := -1, "", -1
+			for event := range events {
+				switch e := event.(type) {
+				case gol.TurnComplete:
+					turn = e.CompletedTurns
+					if turn == 50 {
+						keyPresses <- 'q'
+					}
+				case gol.FinalTurnComplete:
+					final = &e
+				case gol.ImageOutputComplete:
+					filename = e.Filename
+				case gol.StateChange:
+					if e.NewState == gol.Quitting {
+						quitting = e.CompletedTurns
+					}
+				}
+			}
+			if final == nil {
+				t.Fatal("Expected FinalTurnComplete after q was pressed")
+			}
+			if final.CompletedTurns != turn || turn < 50 || turn >= p.Turns {
+				t.Errorf("Expected FinalTurnComplete for the last turn completed, %d, got %d", turn, final.CompletedTurns)
+			}
+			if quitting != final.CompletedTurns {
+				t.Errorf("Expected Quitting at turn %d, got %d", final.CompletedTurns, quitting)
+			}
+			expected := fmt.Sprintf("%dx%dx%d", p.ImageWidth, p.ImageHeight, final.CompletedTurns)
+			if filename != expected {
+				t.Fatalf("Expected the final image to be %v, got %v", expected, filename)
+			}
+			image := readAliveCells(outImage(filename), p.ImageWidth, p.ImageHeight)
+			assertEqualBoard(t, image, final.Alive, p)
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package sdl
+
+import (
+	"math"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// ColourMode selects how the cells are coloured.
+type ColourMode int
+
+const (
+	Binary ColourMode = iota // alive cells are white, dead cells black
+	Age                      // alive cells go from yellow when just born to blue after a few hundred turns
+	Trail                    // alive cells are white, cells that died leave a red trail that fades out
+	Heat                     // every cell is coloured by how often it has flipped since the start
+)
+
+// trailLength is the number of turns a died cell takes to fade out in the Trail mode.
+const trailLength = 32
+
+func (m ColourMode) String() string {
+	switch m {
+	case Binary:
+		return "binary"
+	case Age:
+		return "age"
+	case Trail:
+		return "trail"
+	case Heat:
+		return "heat map"
+	default:
+		return "Incorrect ColourMode"
+	}
+}
+
+// history is what the colour modes need to know about every cell, built from the CellFlipped events.
+type history struct {
+	mode    ColourMode
+	turn    int
+	changed []int    // the turn in which each cell last flipped
+	flips   []uint32 // the number of times each cell has flipped
+}
+
+func newHistory(cells int) history {
+	return history{changed: make([]int, cells), flips: make([]uint32, cells)}
+}
+
+func (h *history) flip(i int) {
+	h.changed[i] = h.turn
+	h.flips[i]++
+}
+
+func (h *history) reset() {
+	for i := range h.changed {
+		h.changed[i] = 0
+		h.flips[i] = 0
+	}
+}
+
+// gradient stops, each a colour at an even step from 0 to 1
+var (
+	ageGradient  = [][3]float64{{255, 255, 160}, {255, 150, 0}, {220, 0, 90}, {90, 40, 220}, {40, 80, 255}}
+	heatGradient = [][3]float64{{0, 0, 0}, {120, 0, 0}, {230, 40, 0}, {255, 200, 0}, {255, 255, 255}}
+	// dying states go from the first, just after alive, to the last, just before dead
+	dyingGradient = [][3]float64{{255, 60, 0}, {200, 0, 160}, {40, 40, 200}, {20, 20, 70}}
+)
+
+// palettes give a colour to every state of the rules that have a name instead of a rulestring.
+var palettes = map[string][][3]uint8{
+	// empty, electron head, electron tail, conductor
+	"Wireworld": {{0, 0, 0}, {60, 140, 255}, {255, 70, 40}, {255, 190, 0}},
+}
+
+// gradient gives the colour at t, from 0 to 1, of a gradient between evenly spaced stops.
+func gradient(stops [][3]float64, t float64) (uint8, uint8, uint8) {
+	t = math.Max(0, math.Min(1, t)) * float64(len(stops)-1)
+	i := int(t)
+	if i == len(stops)-1 {
+		i--
+	}
+	f := t - float64(i)
+	mix := func(c int) uint8 {
+		return uint8(stops[i][c] + (stops[i+1][c]-stops[i][c])*f)
+	}
+	return mix(0), mix(1), mix(2)
+}
+
+// SetStates tells the window that the rule has more than two states, so that the dying states are coloured.
+func (w *Window) SetStates(count int) {
+	w.stateCount = count
+	w.states = make([]uint8, len(w.cells))
+}
+
+// SetPalette colours every state of a rule with more than two states in its own colour instead of
+// alive cells white and dying ones in a gradient.
+func (w *Window) SetPalette(palette [][3]uint8) {
+	w.palette = palette
+}
+
+// SetCellState records the state of a cell from a CellStateChanged event.
+func (w *Window) SetCellState(x, y, state int) {
+	if w.states != nil {
+		w.states[y*int(w.Width)+x] = uint8(state)
+	}
+}
+
+// SetTurn tells the window which turn it is showing, for the ages and trails of the cells.
+func (w *Window) SetTurn(turn int) {
+	w.history.turn = turn
+}
+
+// NextColourMode switches to the next way of colouring the cells.
+func (w *Window) NextColourMode() ColourMode {
+	w.history.mode = (w.history.mode + 1) % (Heat + 1)
+	if w.history.mode == Binary {
+		//the pixels are only inverted on flips in this mode, so they have to start from black and white
+		w.colour(sdl.Rect{W: w.Width, H: w.Height})
+		w.dirtyMin, w.dirtyMax = 0, int(w.Height)-1
+	}
+	return w.history.mode
+}
+
+// colour sets the pixels of the cells inside the rectangle from the colour mode.
+func (w *Window) colour(rect sdl.Rect) {
+	width := int(w.Width)
+	h := &w.history
+	for y := int(rect.Y); y < int(rect.Y+rect.H); y++ {
+		for x := int(rect.X); x < int(rect.X+rect.W); x++ {
+			i := y*width + x
+			var r, g, b uint8
+			switch h.mode {
+			case Binary:
+				if w.palette != nil && w.states != nil && int(w.states[i]) < len(w.palette) {
+					colour := w.palette[w.states[i]]
+					r, g, b = colour[0], colour[1], colour[2]
+				} else if w.cells[i] {
+					r, g, b = 0xFF, 0xFF, 0xFF
+				} else if w.states != nil && w.states[i] > 1 {
+					dying := float64(w.states[i]-2) / math.Max(1, float64(w.stateCount-3))
+					r, g, b = gradient(dyingGradient, dying)
+				}
+			case Age:
+				if w.cells[i] {
+					age := h.turn - h.changed[i]
+					r, g, b = gradient(ageGradient, math.Log2(float64(1+age))/8)
+				}
+			case Trail:
+				if w.cells[i] {
+					r, g, b = 0xFF, 0xFF, 0xFF
+				} else if dead := h.turn - h.changed[i]; h.flips[i] > 0 && dead < trailLength {
+					fade := 1 - float64(dead)/trailLength
+					r, g, b = uint8(220*fade), uint8(40*fade), uint8(40*fade)
+				}
+			case Heat:
+				r, g, b = gradient(heatGradient, math.Log2(float64(1+h.flips[i]))/10)
+			}
+			//ARGB8888 is stored as B, G, R, A on little endian machines
+			w.pixels[4*i+0] = b
+			w.pixels[4*i+1] = g
+			w.pixels[4*i+2] = r
+			w.pixels[4*i+3] = 0xFF
+		}
+	}
+}
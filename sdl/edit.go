@@ -0,0 +1,168 @@
+package sdl
+
+import (
+	"time"
+
+	"github.com/veandco/go-sdl2/sdl"
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// editor turns mouse and key presses into gol.Edits while the game is paused:
+// clicking or dragging with the left button paints cells, dragging with shift held selects a rectangle,
+// Delete or Backspace clears the selection, r randomises it, Escape drops it and v pastes the pattern at the cursor.
+type editor struct {
+	w       *Window
+	paused  bool
+	pattern [][]uint8
+	density float64
+	// pending holds the edits that have not been taken by the distributor yet.
+	pending   []gol.Edit
+	painting  bool
+	alive     bool // what painting makes the cells
+	selecting bool
+	last      util.Cell // the last cell painted, or the corner the selection started from
+	// plane is the view of the unbounded plane, whose cells are not where they are in the window, nil on a board.
+	plane *planeView
+}
+
+// queue adds an edit of the cells of the window to the pending edits, with the cells of the plane instead on
+// the unbounded plane.
+func (e *editor) queue(edit gol.Edit) {
+	if e.plane != nil {
+		edit.Cell, edit.Corner = e.plane.place(edit.Cell), e.plane.place(edit.Corner)
+	}
+	e.pending = append(e.pending, edit)
+}
+
+// mouseDown starts painting or selecting, and tells whether it did so. Otherwise the press starts a pan.
+func (e *editor) mouseDown(button uint8, screenX, screenY int32) bool {
+	x, y, onBoard := e.w.CellAt(screenX, screenY)
+	if !e.paused || button != sdl.BUTTON_LEFT || !onBoard {
+		return false
+	}
+	e.last = util.Cell{X: x, Y: y}
+	if sdl.GetModState()&sdl.KMOD_SHIFT != 0 {
+		e.selecting = true
+		e.w.Select(x, y, x, y)
+		return true
+	}
+	e.painting = true
+	e.alive = !e.w.Alive(x, y)
+	e.queue(gol.Edit{Kind: gol.EditSet, Cell: e.last, Alive: e.alive})
+	return true
+}
+
+// mouseMove paints every cell on the line from the last cell painted, or grows the selection.
+// It tells whether the view has to be drawn again.
+func (e *editor) mouseMove(screenX, screenY int32) bool {
+	if !e.painting && !e.selecting {
+		return false
+	}
+	x, y, _ := e.w.CellAt(screenX, screenY)
+	x = clamp(x, 0, int(e.w.Width)-1)
+	y = clamp(y, 0, int(e.w.Height)-1)
+	if e.selecting {
+		e.w.Select(e.last.X, e.last.Y, x, y)
+		return true
+	}
+	for _, cell := range line(e.last, util.Cell{X: x, Y: y})[1:] {
+		e.queue(gol.Edit{Kind: gol.EditSet, Cell: cell, Alive: e.alive})
+	}
+	e.last = util.Cell{X: x, Y: y}
+	return false
+}
+
+func (e *editor) mouseUp() {
+	e.painting = false
+	e.selecting = false
+}
+
+// key handles the editing keys, and tells whether the view has to be drawn again.
+func (e *editor) key(sym sdl.Keycode) bool {
+	if !e.paused {
+		return false
+	}
+	x0, y0, x1, y1, selected := e.w.Selection()
+	corners := func(kind gol.EditKind) gol.Edit {
+		return gol.Edit{Kind: kind, Cell: util.Cell{X: x0, Y: y0}, Corner: util.Cell{X: x1, Y: y1}}
+	}
+	switch sym {
+	case sdl.K_DELETE, sdl.K_BACKSPACE:
+		if selected {
+			e.queue(corners(gol.EditClear))
+		}
+	case sdl.K_r:
+		if selected {
+			edit := corners(gol.EditRandomise)
+			edit.Density, edit.Seed = e.density, time.Now().UnixNano()
+			e.queue(edit)
+		}
+	case sdl.K_v:
+		mouseX, mouseY, _ := sdl.GetMouseState()
+		x, y, onBoard := e.w.CellAt(mouseX, mouseY)
+		if e.pattern != nil && onBoard {
+			e.queue(gol.Edit{Kind: gol.EditPaste, Cell: util.Cell{X: x, Y: y}, Pattern: e.pattern})
+		}
+	case sdl.K_ESCAPE:
+		e.w.ClearSelection()
+		return true
+	}
+	return false
+}
+
+// send gives the next pending edit to the distributor if it is ready for it.
+// It never blocks, because the distributor may be waiting for this goroutine to take its events.
+func (e *editor) send(edits chan<- gol.Edit) {
+	if len(e.pending) == 0 {
+		return
+	}
+	select {
+	case edits <- e.pending[0]:
+		e.pending = e.pending[1:]
+	default:
+	}
+}
+
+// line gives the cells from a to b, both included, using Bresenham's algorithm.
+func line(a, b util.Cell) []util.Cell {
+	dx, dy := abs(b.X-a.X), -abs(b.Y-a.Y)
+	stepX, stepY := 1, 1
+	if a.X > b.X {
+		stepX = -1
+	}
+	if a.Y > b.Y {
+		stepY = -1
+	}
+	cells := []util.Cell{a}
+	err := dx + dy
+	for a != b {
+		if 2*err >= dy {
+			err += dy
+			a.X += stepX
+		}
+		if 2*err <= dx {
+			err += dx
+			a.Y += stepY
+		}
+		cells = append(cells, a)
+	}
+	return cells
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func clamp(value, min, max int) int {
+	if value > max {
+		value = max
+	}
+	if value < min {
+		value = min
+	}
+	return value
+}
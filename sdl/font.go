@@ -0,0 +1,109 @@
+package sdl
+
+import (
+	"strings"
+
+	"github.com/veandco/go-sdl2/sdl"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// glyphs is a 5x7 pixel font for the HUD, as SDL cannot draw text on its own.
+// Every glyph is 7 rows from the top, and bit 4 of a row is its leftmost pixel.
+var glyphs = map[rune][7]uint8{
+	'0': {0x0E, 0x11, 0x13, 0x15, 0x19, 0x11, 0x0E},
+	'1': {0x04, 0x0C, 0x04, 0x04, 0x04, 0x04, 0x0E},
+	'2': {0x0E, 0x11, 0x01, 0x02, 0x04, 0x08, 0x1F},
+	'3': {0x1F, 0x02, 0x04, 0x02, 0x01, 0x11, 0x0E},
+	'4': {0x02, 0x06, 0x0A, 0x12, 0x1F, 0x02, 0x02},
+	'5': {0x1F, 0x10, 0x1E, 0x01, 0x01, 0x11, 0x0E},
+	'6': {0x06, 0x08, 0x10, 0x1E, 0x11, 0x11, 0x0E},
+	'7': {0x1F, 0x01, 0x02, 0x04, 0x08, 0x08, 0x08},
+	'8': {0x0E, 0x11, 0x11, 0x0E, 0x11, 0x11, 0x0E},
+	'9': {0x0E, 0x11, 0x11, 0x0F, 0x01, 0x02, 0x0C},
+	'A': {0x0E, 0x11, 0x11, 0x1F, 0x11, 0x11, 0x11},
+	'B': {0x1E, 0x11, 0x11, 0x1E, 0x11, 0x11, 0x1E},
+	'C': {0x0E, 0x11, 0x10, 0x10, 0x10, 0x11, 0x0E},
+	'D': {0x1C, 0x12, 0x11, 0x11, 0x11, 0x12, 0x1C},
+	'E': {0x1F, 0x10, 0x10, 0x1E, 0x10, 0x10, 0x1F},
+	'F': {0x1F, 0x10, 0x10, 0x1E, 0x10, 0x10, 0x10},
+	'G': {0x0E, 0x11, 0x10, 0x17, 0x11, 0x11, 0x0F},
+	'H': {0x11, 0x11, 0x11, 0x1F, 0x11, 0x11, 0x11},
+	'I': {0x0E, 0x04, 0x04, 0x04, 0x04, 0x04, 0x0E},
+	'J': {0x07, 0x02, 0x02, 0x02, 0x02, 0x12, 0x0C},
+	'K': {0x11, 0x12, 0x14, 0x18, 0x14, 0x12, 0x11},
+	'L': {0x10, 0x10, 0x10, 0x10, 0x10, 0x10, 0x1F},
+	'M': {0x11, 0x1B, 0x15, 0x15, 0x11, 0x11, 0x11},
+	'N': {0x11, 0x11, 0x19, 0x15, 0x13, 0x11, 0x11},
+	'O': {0x0E, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0E},
+	'P': {0x1E, 0x11, 0x11, 0x1E, 0x10, 0x10, 0x10},
+	'Q': {0x0E, 0x11, 0x11, 0x11, 0x15, 0x12, 0x0D},
+	'R': {0x1E, 0x11, 0x11, 0x1E, 0x14, 0x12, 0x11},
+	'S': {0x0F, 0x10, 0x10, 0x0E, 0x01, 0x01, 0x1E},
+	'T': {0x1F, 0x04, 0x04, 0x04, 0x04, 0x04, 0x04},
+	'U': {0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0E},
+	'V': {0x11, 0x11, 0x11, 0x11, 0x11, 0x0A, 0x04},
+	'W': {0x11, 0x11, 0x11, 0x15, 0x15, 0x15, 0x0A},
+	'X': {0x11, 0x11, 0x0A, 0x04, 0x0A, 0x11, 0x11},
+	'Y': {0x11, 0x11, 0x11, 0x0A, 0x04, 0x04, 0x04},
+	'Z': {0x1F, 0x01, 0x02, 0x04, 0x08, 0x10, 0x1F},
+	'.': {0x00, 0x00, 0x00, 0x00, 0x00, 0x0C, 0x0C},
+	':': {0x00, 0x0C, 0x0C, 0x00, 0x0C, 0x0C, 0x00},
+	'/': {0x00, 0x01, 0x02, 0x04, 0x08, 0x10, 0x00},
+	'-': {0x00, 0x00, 0x00, 0x1F, 0x00, 0x00, 0x00},
+	'%': {0x18, 0x19, 0x02, 0x04, 0x08, 0x13, 0x03},
+}
+
+const (
+	glyphWidth  = 5
+	glyphHeight = 7
+	// hudScale is the size in screen pixels of one pixel of the font.
+	hudScale   = 2
+	hudPadding = 6
+)
+
+// drawHUD draws the lines of text in a translucent box in the top-left corner of the window.
+// It draws straight on the renderer, over the cells, so the texture of the board is left as it is.
+func (w *Window) drawHUD(lines []string) {
+	longest := 0
+	for _, line := range lines {
+		if len(line) > longest {
+			longest = len(line)
+		}
+	}
+	advance := int32((glyphWidth + 1) * hudScale)
+	lineHeight := int32((glyphHeight + 3) * hudScale)
+	box := sdl.Rect{
+		X: 0, Y: 0,
+		W: int32(longest)*advance + 2*hudPadding,
+		H: int32(len(lines))*lineHeight + 2*hudPadding - 2*hudScale,
+	}
+	util.Check(w.renderer.SetDrawBlendMode(sdl.BLENDMODE_BLEND))
+	util.Check(w.renderer.SetDrawColor(0, 0, 0, 0xB0))
+	util.Check(w.renderer.FillRect(&box))
+	util.Check(w.renderer.SetDrawBlendMode(sdl.BLENDMODE_NONE))
+
+	var pixels []sdl.Rect
+	for row, line := range lines {
+		for column, char := range strings.ToUpper(line) {
+			glyph, ok := glyphs[char]
+			if !ok {
+				continue
+			}
+			x := hudPadding + int32(column)*advance
+			y := hudPadding + int32(row)*lineHeight
+			for gy, bits := range glyph {
+				for gx := 0; gx < glyphWidth; gx++ {
+					if bits&(1<<uint(glyphWidth-1-gx)) != 0 {
+						pixels = append(pixels, sdl.Rect{
+							X: x + int32(gx*hudScale), Y: y + int32(gy*hudScale), W: hudScale, H: hudScale,
+						})
+					}
+				}
+			}
+		}
+	}
+	if len(pixels) > 0 {
+		util.Check(w.renderer.SetDrawColor(0xFF, 0xFF, 0xFF, 0xFF))
+		util.Check(w.renderer.FillRects(pixels))
+	}
+}
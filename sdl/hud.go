@@ -0,0 +1,74 @@
+package sdl
+
+import (
+	"fmt"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+)
+
+// hud keeps the status of the run shown over the board, fed from the events.
+type hud struct {
+	visible bool
+	threads int
+	turn    int
+	state   gol.State
+	// turns per second, measured over rateInterval
+	rate     float64
+	rateTurn int
+	rateTime time.Time
+	// plane is the view of the unbounded plane, whose cells are not all in the window, nil on a board.
+	plane *planeView
+}
+
+const rateInterval = time.Second
+
+func newHUD(threads int) *hud {
+	return &hud{visible: true, threads: threads, state: gol.Executing, rateTime: time.Now()}
+}
+
+// update takes the turn and state from an event.
+func (h *hud) update(event gol.Event) {
+	switch e := event.(type) {
+	case gol.TurnComplete:
+		h.turn = e.CompletedTurns
+		if elapsed := time.Since(h.rateTime); elapsed >= rateInterval {
+			h.rate = float64(h.turn-h.rateTurn) / elapsed.Seconds()
+			h.rateTurn, h.rateTime = h.turn, time.Now()
+		}
+	case gol.StateChange:
+		h.state = e.NewState
+		if e.NewState != gol.Executing {
+			h.rate = 0
+		}
+		h.rateTurn, h.rateTime = e.CompletedTurns, time.Now()
+	case gol.ThreadsChanged:
+		h.threads = e.Threads
+	}
+}
+
+// lines gives the text of the HUD, or nothing if it is hidden.
+func (h *hud) lines(w *Window) []string {
+	if !h.visible {
+		return nil
+	}
+	alive := w.CountPixels()
+	if h.plane != nil {
+		alive = h.plane.alive
+	}
+	lines := []string{
+		fmt.Sprintf("turn %d", h.turn),
+		fmt.Sprintf("alive %d", alive),
+		fmt.Sprintf("%.1f turns/s", h.rate),
+		fmt.Sprintf("%d threads", h.threads),
+		fmt.Sprintf("%v - %v", h.state, w.history.mode),
+	}
+	if h.plane != nil {
+		following := ""
+		if h.plane.follow {
+			following = " following"
+		}
+		lines = append(lines, fmt.Sprintf("at %d, %d%s", h.plane.origin.X, h.plane.origin.Y, following))
+	}
+	return lines
+}
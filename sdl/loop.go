@@ -2,19 +2,78 @@ package sdl
 
 import (
 	"fmt"
+	"math"
+
 	"github.com/veandco/go-sdl2/sdl"
 	"uk.ac.bris.cs/gameoflife/gol"
 )
 
-func Run(p gol.Params, events <-chan gol.Event, keyPresses chan<- rune) {
+// zoomStep is how much one notch of the mouse wheel or one press of + or - zooms.
+const zoomStep = 1.25
+
+// panStep is how many pixels the arrow keys move the board.
+const panStep = 32
+
+// Run shows the board in an SDL window. p, s, q, k and b are sent to keyPresses.
+// The mouse wheel, + and - zoom, dragging with any mouse button or the arrow keys pan,
+// f fits the board to the window, g turns the grid lines on or off, c cycles through the colour modes
+// and h shows or hides the HUD with the turn, alive cells, turns per second, threads and state.
+// While paused the left mouse button edits the board instead of panning, see editor, and the edits are sent
+// to edits. The pattern pasted with v is the -pattern file of p.Generator.
+// On the unbounded plane of p.Unbounded the window shows a board-sized part of the plane, see planeView:
+// the arrow keys move it over the plane instead of panning, and o goes back to following the cells.
+func Run(p gol.Params, events <-chan gol.Event, keyPresses chan<- rune, edits chan<- gol.Edit) {
 	w := NewWindow(int32(p.ImageWidth), int32(p.ImageHeight))
+	dragging := false
+	edit := &editor{w: w, density: p.Generator.Density}
+	status := newHUD(p.Threads)
+	var plane *planeView
+	rule, err := gol.ParseRule(p.Rule)
+	if err == nil {
+		if rule.States > 2 {
+			w.SetStates(rule.States)
+		}
+		if palette, ok := palettes[rule.Name]; ok {
+			w.SetPalette(palette)
+		}
+		if rule.Neighbourhood == gol.Hexagonal {
+			w.SetHexagonal()
+		}
+	}
+	if p.Unbounded {
+		plane = newPlaneView(err == nil && rule.States > 2)
+		edit.plane, status.plane = plane, plane
+	}
+	//move gives the arrow keys their job: moving the window over the plane, or panning
+	move := func(dx, dy int) {
+		if plane != nil {
+			plane.move(w, dx, dy)
+		} else {
+			w.Pan(int32(-dx*panStep), int32(-dy*panStep))
+		}
+	}
+	if p.Turmite != "" {
+		if turmite, err := gol.ParseTurmite(p.Turmite); err == nil && turmite.Colours() > 2 {
+			w.SetStates(turmite.Colours())
+		}
+	}
+	if p.Generator.Pattern != "" {
+		pattern, err := gol.ReadPattern(p.Generator.Pattern, rule)
+		if err != nil {
+			fmt.Println("Cannot paste:", err)
+		}
+		edit.pattern = pattern
+	}
 
 sdlLoop:
 	for {
 		event := w.PollEvent()
 		if event != nil {
+			//the view changes are drawn straight away, so that they also show while paused
+			viewChanged := true
 			switch e := event.(type) {
 			case *sdl.KeyboardEvent:
+				viewChanged = edit.key(e.Keysym.Sym)
 				switch e.Keysym.Sym {
 				case sdl.K_p:
 					keyPresses <- 'p'
@@ -24,20 +83,116 @@ sdlLoop:
 					keyPresses <- 'q'
 				case sdl.K_k:
 					keyPresses <- 'k'
+				case sdl.K_b:
+					keyPresses <- 'b'
+				case sdl.K_f:
+					w.Fit()
+					viewChanged = true
+				case sdl.K_g:
+					w.ToggleGrid()
+					viewChanged = true
+				case sdl.K_c:
+					fmt.Println("Colour mode:", w.NextColourMode())
+					viewChanged = true
+				case sdl.K_h:
+					status.visible = !status.visible
+					viewChanged = true
+				case sdl.K_EQUALS, sdl.K_PLUS:
+					w.ZoomAt(zoomStep, w.view.width/2, w.view.height/2)
+					viewChanged = true
+				case sdl.K_MINUS:
+					w.ZoomAt(1/zoomStep, w.view.width/2, w.view.height/2)
+					viewChanged = true
+				case sdl.K_LEFT:
+					move(-1, 0)
+					viewChanged = true
+				case sdl.K_RIGHT:
+					move(1, 0)
+					viewChanged = true
+				case sdl.K_UP:
+					move(0, -1)
+					viewChanged = true
+				case sdl.K_DOWN:
+					move(0, 1)
+					viewChanged = true
+				case sdl.K_o:
+					if plane != nil {
+						plane.toggleFollow(w)
+					}
+					viewChanged = true
+				}
+			case *sdl.MouseWheelEvent:
+				x, y, _ := sdl.GetMouseState()
+				w.ZoomAt(math.Pow(zoomStep, float64(e.Y)), x, y)
+			case *sdl.MouseButtonEvent:
+				viewChanged = false
+				if e.State == sdl.PRESSED {
+					dragging = !edit.mouseDown(e.Button, e.X, e.Y)
+					viewChanged = !dragging
+				} else {
+					dragging = false
+					edit.mouseUp()
+				}
+			case *sdl.MouseMotionEvent:
+				viewChanged = edit.mouseMove(e.X, e.Y)
+				if dragging {
+					w.Pan(e.XRel, e.YRel)
+					viewChanged = true
+				}
+			case *sdl.WindowEvent:
+				viewChanged = e.Event == sdl.WINDOWEVENT_SIZE_CHANGED
+				if viewChanged {
+					w.Resize(e.Data1, e.Data2)
 				}
+			default:
+				viewChanged = false
+			}
+			if viewChanged {
+				w.SetHUD(status.lines(w))
+				w.RenderFrame()
 			}
 		}
+		edit.send(edits)
 		select {
 		case event, ok := <-events:
 			if !ok {
 				w.Destroy()
 				break sdlLoop
 			}
+			status.update(event)
 			switch e := event.(type) {
 			case gol.CellFlipped:
-				w.FlipPixel(e.Cell.X, e.Cell.Y)
+				if plane != nil {
+					plane.flip(w, e.Cell)
+				} else {
+					w.FlipPixel(e.Cell.X, e.Cell.Y)
+				}
+			case gol.CellStateChanged:
+				if plane != nil {
+					plane.setState(w, e.Cell, e.State)
+				} else {
+					w.SetCellState(e.Cell.X, e.Cell.Y, e.State)
+				}
+			case gol.AntsMoved:
+				w.SetAnts(e.Ants)
 			case gol.TurnComplete:
+				if plane != nil {
+					plane.centre(w)
+				}
+				w.SetTurn(e.CompletedTurns)
+				w.SetHUD(status.lines(w))
+				w.RenderFrame()
+			case gol.WorldEdited:
+				w.SetHUD(status.lines(w))
+				w.RenderFrame()
+			case gol.StateChange:
+				edit.paused = e.NewState == gol.Paused
+				if !edit.paused {
+					w.ClearSelection()
+				}
+				w.SetHUD(status.lines(w))
 				w.RenderFrame()
+				fmt.Printf("Completed Turns %-8v%v\n", event.GetCompletedTurns(), event)
 			case gol.FinalTurnComplete:
 				w.Destroy()
 				break sdlLoop
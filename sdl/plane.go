@@ -0,0 +1,150 @@
+package sdl
+
+import (
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// planeView shows the unbounded plane of gol.Params.Unbounded, which has no size the window could hold,
+// through a window the size of the board. It keeps every cell of the plane that is not dead, from the events,
+// and draws the ones inside the window. The window follows the centre of the cells, moving once the centre
+// leaves the middle half of the window, until the user moves it.
+type planeView struct {
+	// cells holds the state of every cell that is not dead, 1 for the alive ones.
+	cells map[util.Cell]int
+	// states is set for rules with more than two states, whose cells take their states from CellStateChanged
+	// events instead of CellFlipped ones.
+	states bool
+	alive  int
+	// origin is the cell of the plane in the top-left corner of the window.
+	origin util.Cell
+	follow bool
+}
+
+func newPlaneView(states bool) *planeView {
+	return &planeView{cells: make(map[util.Cell]int), states: states, follow: true}
+}
+
+// inView gives where cell is in the window, and whether it is inside it.
+func (v *planeView) inView(w *Window, cell util.Cell) (int, int, bool) {
+	x, y := cell.X-v.origin.X, cell.Y-v.origin.Y
+	return x, y, x >= 0 && y >= 0 && x < int(w.Width) && y < int(w.Height)
+}
+
+// flip records a CellFlipped event, and flips the pixel of the cell if it is in the window.
+func (v *planeView) flip(w *Window, cell util.Cell) {
+	if v.cells[cell] == 1 {
+		v.alive--
+		if !v.states {
+			delete(v.cells, cell)
+		}
+	} else {
+		v.alive++
+		if !v.states {
+			v.cells[cell] = 1
+		}
+	}
+	if x, y, ok := v.inView(w, cell); ok {
+		w.FlipPixel(x, y)
+	}
+}
+
+// setState records a CellStateChanged event, which comes after the CellFlipped event of the same cell.
+func (v *planeView) setState(w *Window, cell util.Cell, state int) {
+	if state == 0 {
+		delete(v.cells, cell)
+	} else {
+		v.cells[cell] = state
+	}
+	if x, y, ok := v.inView(w, cell); ok {
+		w.SetCellState(x, y, state)
+	}
+}
+
+// middle gives the centre of the smallest rectangle around the cells, and false if there are none.
+func (v *planeView) middle() (util.Cell, bool) {
+	first := true
+	var min, max util.Cell
+	for cell := range v.cells {
+		if first {
+			min, max, first = cell, cell, false
+			continue
+		}
+		min.X, min.Y = minInt(min.X, cell.X), minInt(min.Y, cell.Y)
+		max.X, max.Y = maxInt(max.X, cell.X), maxInt(max.Y, cell.Y)
+	}
+	return util.Cell{X: (min.X + max.X) / 2, Y: (min.Y + max.Y) / 2}, !first
+}
+
+// centre moves the window to the centre of the cells if it follows them and the centre has left the middle
+// half of the window, and tells whether it moved.
+func (v *planeView) centre(w *Window) bool {
+	middle, ok := v.middle()
+	if !v.follow || !ok {
+		return false
+	}
+	width, height := int(w.Width), int(w.Height)
+	x, y := middle.X-v.origin.X, middle.Y-v.origin.Y
+	if x >= width/4 && x < width-width/4 && y >= height/4 && y < height-height/4 {
+		return false
+	}
+	v.goTo(w, middle)
+	return true
+}
+
+// goTo moves the window so that cell is in its centre.
+func (v *planeView) goTo(w *Window, cell util.Cell) {
+	v.origin = util.Cell{X: cell.X - int(w.Width)/2, Y: cell.Y - int(w.Height)/2}
+	v.draw(w)
+}
+
+// move moves the window by a quarter of its size in the direction of dx and dy, and stops following the cells.
+func (v *planeView) move(w *Window, dx, dy int) {
+	v.follow = false
+	v.origin.X += dx * int(w.Width) / 4
+	v.origin.Y += dy * int(w.Height) / 4
+	v.draw(w)
+}
+
+// toggleFollow starts or stops following the centre of the cells, going to it straight away.
+func (v *planeView) toggleFollow(w *Window) {
+	v.follow = !v.follow
+	if middle, ok := v.middle(); v.follow && ok {
+		v.goTo(w, middle)
+	}
+}
+
+// draw sets the pixels of the window from the cells inside it.
+func (v *planeView) draw(w *Window) {
+	w.ClearPixels()
+	for cell, state := range v.cells {
+		x, y, ok := v.inView(w, cell)
+		if !ok {
+			continue
+		}
+		if state == 1 {
+			w.SetPixel(x, y)
+		}
+		if v.states {
+			w.SetCellState(x, y, state)
+		}
+	}
+}
+
+// place gives the cell of the plane at cell of the window.
+func (v *planeView) place(cell util.Cell) util.Cell {
+	return util.Cell{X: cell.X + v.origin.X, Y: cell.Y + v.origin.Y}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,141 @@
+package sdl
+
+import (
+	"math"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const (
+	// maxWindow is the largest side of the window when it opens, larger boards start zoomed out.
+	maxWindow = 1024
+	// minWindow is the side a small board is scaled up to, in whole pixels per cell, when the window opens.
+	minWindow = 512
+	minZoom   = 1.0 / 64
+	maxZoom   = 128
+	// minGridZoom is the number of pixels per cell from which grid lines are drawn.
+	minGridZoom = 6
+)
+
+// viewport is the part of the board shown in the window.
+// The cell at (x, y) is drawn at the top-left corner of the window, zoom pixels wide.
+type viewport struct {
+	width, height int32 // size of the window in pixels
+	x, y          float64
+	zoom          float64
+	grid          bool
+}
+
+// initialSize gives the size of a new window for a width x height board: small boards are scaled up by a whole
+// number of pixels per cell and boards larger than any screen are scaled down to fit maxWindow.
+func initialSize(width, height int32) (int32, int32) {
+	side := width
+	if height > side {
+		side = height
+	}
+	if side > maxWindow {
+		scale := float64(maxWindow) / float64(side)
+		return int32(math.Max(1, float64(width)*scale)), int32(math.Max(1, float64(height)*scale))
+	}
+	scale := minWindow / side
+	if scale < 1 {
+		scale = 1
+	}
+	return width * scale, height * scale
+}
+
+// visible gives the rectangle of cells that is inside the window, and where it is drawn on the window.
+func (v viewport) visible(width, height int32) (src, dst sdl.Rect) {
+	x0 := math.Max(0, math.Floor(v.x))
+	y0 := math.Max(0, math.Floor(v.y))
+	x1 := math.Min(float64(width), math.Ceil(v.x+float64(v.width)/v.zoom))
+	y1 := math.Min(float64(height), math.Ceil(v.y+float64(v.height)/v.zoom))
+	if x1 <= x0 || y1 <= y0 {
+		return sdl.Rect{}, sdl.Rect{}
+	}
+	src = sdl.Rect{X: int32(x0), Y: int32(y0), W: int32(x1 - x0), H: int32(y1 - y0)}
+	dst = sdl.Rect{
+		X: int32(math.Round((x0 - v.x) * v.zoom)),
+		Y: int32(math.Round((y0 - v.y) * v.zoom)),
+		W: int32(math.Round((x1 - x0) * v.zoom)),
+		H: int32(math.Round((y1 - y0) * v.zoom)),
+	}
+	return src, dst
+}
+
+// Fit zooms so that the whole board fills the window, and centres it.
+func (w *Window) Fit() {
+	w.view.zoom = math.Min(float64(w.view.width)/float64(w.Width), float64(w.view.height)/float64(w.Height))
+	w.view.x = (float64(w.Width) - float64(w.view.width)/w.view.zoom) / 2
+	w.view.y = (float64(w.Height) - float64(w.view.height)/w.view.zoom) / 2
+}
+
+// ZoomAt multiplies the zoom by factor, keeping the cell under the pixel (screenX, screenY) where it is.
+func (w *Window) ZoomAt(factor float64, screenX, screenY int32) {
+	cellX := w.view.x + float64(screenX)/w.view.zoom
+	cellY := w.view.y + float64(screenY)/w.view.zoom
+	w.view.zoom = math.Max(minZoom, math.Min(maxZoom, w.view.zoom*factor))
+	w.view.x = cellX - float64(screenX)/w.view.zoom
+	w.view.y = cellY - float64(screenY)/w.view.zoom
+	w.clampView()
+}
+
+// Pan moves the board by dx, dy pixels, like dragging it with the mouse.
+func (w *Window) Pan(dx, dy int32) {
+	w.view.x -= float64(dx) / w.view.zoom
+	w.view.y -= float64(dy) / w.view.zoom
+	w.clampView()
+}
+
+// clampView keeps at least one cell of the board inside the window.
+func (w *Window) clampView() {
+	w.view.x = math.Max(1-float64(w.view.width)/w.view.zoom, math.Min(float64(w.Width)-1, w.view.x))
+	w.view.y = math.Max(1-float64(w.view.height)/w.view.zoom, math.Min(float64(w.Height)-1, w.view.y))
+}
+
+// CellAt gives the cell under the pixel (screenX, screenY), and whether that pixel is on the board at all.
+func (w *Window) CellAt(screenX, screenY int32) (int, int, bool) {
+	y := int(math.Floor(w.view.y + float64(screenY)/w.view.zoom))
+	//odd rows of hexagonal boards are drawn further right
+	screenX -= w.rowOffset(y)
+	x := int(math.Floor(w.view.x + float64(screenX)/w.view.zoom))
+	return x, y, x >= 0 && y >= 0 && x < int(w.Width) && y < int(w.Height)
+}
+
+// Select outlines the rectangle of cells with corners (x0, y0) and (x1, y1).
+func (w *Window) Select(x0, y0, x1, y1 int) {
+	if x0 > x1 {
+		x0, x1 = x1, x0
+	}
+	if y0 > y1 {
+		y0, y1 = y1, y0
+	}
+	w.selection = sdl.Rect{X: int32(x0), Y: int32(y0), W: int32(x1 - x0 + 1), H: int32(y1 - y0 + 1)}
+	w.hasSelection = true
+}
+
+// Selection gives the corners of the selected rectangle, and whether anything is selected.
+func (w *Window) Selection() (int, int, int, int, bool) {
+	s := w.selection
+	return int(s.X), int(s.Y), int(s.X + s.W - 1), int(s.Y + s.H - 1), w.hasSelection
+}
+
+func (w *Window) ClearSelection() {
+	w.hasSelection = false
+}
+
+// ToggleGrid turns the grid lines between cells on or off. They are only drawn once the cells are large enough.
+func (w *Window) ToggleGrid() {
+	w.view.grid = !w.view.grid
+}
+
+// Resize tells the viewport the new size of the window, keeping the cell in the centre where it is.
+func (w *Window) Resize(width, height int32) {
+	if width <= 0 || height <= 0 {
+		return
+	}
+	w.view.x += float64(w.view.width-width) / 2 / w.view.zoom
+	w.view.y += float64(w.view.height-height) / 2 / w.view.zoom
+	w.view.width, w.view.height = width, height
+	w.clampView()
+}
@@ -2,45 +2,81 @@ package sdl
 
 import (
 	"fmt"
+	"math"
 
 	"github.com/veandco/go-sdl2/sdl"
+	"uk.ac.bris.cs/gameoflife/gol"
 	"uk.ac.bris.cs/gameoflife/util"
 )
 
+// Window shows the board, Width x Height cells, through a viewport that can be zoomed and panned.
 type Window struct {
 	Width, Height int32
 	window        *sdl.Window
 	renderer      *sdl.Renderer
 	texture       *sdl.Texture
 	pixels        []byte
+	// cells holds which cells are alive, as in the colour modes the pixels are not just black or white.
+	cells   []bool
+	alive   int
+	history history
+	// states holds the state of every cell for rules with more than two states, nil otherwise.
+	states     []uint8
+	stateCount int
+	// palette gives the colour of every state of a named rule, like Wireworld, nil otherwise.
+	palette [][3]uint8
+	// hud is the text drawn over the board, nothing if it is empty.
+	hud []string
+	// dirtyMin and dirtyMax are the first and last rows changed since the texture was last updated.
+	dirtyMin, dirtyMax int
+	view               viewport
+	// selection is the rectangle of cells outlined on the board, if hasSelection is set.
+	selection    sdl.Rect
+	hasSelection bool
+	// ants are the cells with an ant of a turmite on them, drawn over the board.
+	ants []sdl.Rect
+	// hexagonal draws the odd rows half a cell to the right, for rules on a hexagonal grid.
+	hexagonal bool
 }
 
 func filterEvent(e sdl.Event, userdata interface{}) bool {
-	return e.GetType() == sdl.KEYDOWN || e.GetType() == sdl.QUIT
+	switch e.GetType() {
+	case sdl.KEYDOWN, sdl.QUIT, sdl.WINDOWEVENT, sdl.MOUSEWHEEL, sdl.MOUSEMOTION, sdl.MOUSEBUTTONDOWN, sdl.MOUSEBUTTONUP:
+		return true
+	}
+	return false
 }
 
 func NewWindow(width, height int32) *Window {
 	err := sdl.Init(sdl.INIT_EVERYTHING)
 	util.Check(err)
-	window, err := sdl.CreateWindow("GOL GUI", sdl.WINDOWPOS_CENTERED, sdl.WINDOWPOS_CENTERED, width, height, sdl.WINDOW_SHOWN)
+	windowWidth, windowHeight := initialSize(width, height)
+	window, err := sdl.CreateWindow("GOL GUI", sdl.WINDOWPOS_CENTERED, sdl.WINDOWPOS_CENTERED,
+		windowWidth, windowHeight, sdl.WINDOW_SHOWN|sdl.WINDOW_RESIZABLE)
 	util.Check(err)
 	renderer, err := sdl.CreateRenderer(window, -1, sdl.WINDOW_SHOWN)
 	util.Check(err)
-	sdl.SetHint(sdl.HINT_RENDER_SCALE_QUALITY, "linear")
-	err = renderer.SetLogicalSize(width, height)
-	util.Check(err)
+	//cells are drawn as sharp squares when zoomed in
+	sdl.SetHint(sdl.HINT_RENDER_SCALE_QUALITY, "nearest")
 	texture, err := renderer.CreateTexture(sdl.PIXELFORMAT_ARGB8888, sdl.TEXTUREACCESS_STATIC, width, height)
 	util.Check(err)
 
 	sdl.SetEventFilterFunc(filterEvent, nil)
-	return &Window{
-		width,
-		height,
-		window,
-		renderer,
-		texture,
-		make([]byte, width*height*4),
+	w := &Window{
+		Width:    width,
+		Height:   height,
+		window:   window,
+		renderer: renderer,
+		texture:  texture,
+		pixels:   make([]byte, width*height*4),
+		cells:    make([]bool, width*height),
+		history:  newHistory(int(width * height)),
+		dirtyMin: 0,
+		dirtyMax: int(height) - 1,
 	}
+	w.view.width, w.view.height = windowWidth, windowHeight
+	w.Fit()
+	return w
 }
 
 func (w *Window) Destroy() {
@@ -53,26 +89,163 @@ func (w *Window) Destroy() {
 	sdl.Quit()
 }
 
+// RenderFrame uploads the rows changed since the last frame to the texture and draws the part of the board
+// inside the viewport, with grid lines between the cells if they are turned on and the cells are large enough.
 func (w *Window) RenderFrame() {
-	err := w.texture.Update(nil, w.pixels, int(w.Width*4))
+	src, dst := w.view.visible(w.Width, w.Height)
+	if (w.history.mode != Binary || w.states != nil) && src.W > 0 && src.H > 0 {
+		//the colours change every turn even where no cell flipped, so the whole view is coloured again
+		w.colour(src)
+		start := 4 * (int(src.Y)*int(w.Width) + int(src.X))
+		err := w.texture.Update(&src, w.pixels[start:], int(w.Width)*4)
+		util.Check(err)
+	} else if w.dirtyMin <= w.dirtyMax {
+		width := int(w.Width)
+		rows := &sdl.Rect{X: 0, Y: int32(w.dirtyMin), W: w.Width, H: int32(w.dirtyMax - w.dirtyMin + 1)}
+		err := w.texture.Update(rows, w.pixels[4*w.dirtyMin*width:4*(w.dirtyMax+1)*width], width*4)
+		util.Check(err)
+		w.dirtyMin, w.dirtyMax = int(w.Height), -1
+	}
+	err := w.renderer.SetDrawColor(0x20, 0x20, 0x20, 0xFF)
 	util.Check(err)
 	err = w.renderer.Clear()
 	util.Check(err)
-	err = w.renderer.Copy(w.texture, nil, nil)
-	util.Check(err)
+	if src.W > 0 && src.H > 0 {
+		w.drawBoard(src, dst)
+		if w.view.grid && w.view.zoom >= minGridZoom {
+			w.drawGrid(src, dst)
+		}
+	}
+	if len(w.ants) > 0 {
+		w.drawAnts()
+	}
+	if w.hasSelection {
+		w.drawSelection()
+	}
+	if len(w.hud) > 0 {
+		w.drawHUD(w.hud)
+	}
 	w.renderer.Present()
 }
 
+// SetHexagonal draws the board as a hexagonal grid stored in offset rows, with the odd rows half a cell to the right.
+func (w *Window) SetHexagonal() {
+	w.hexagonal = true
+}
+
+// drawBoard copies the cells in src to dst. The rows of hexagonal boards are copied one by one once the cells
+// are large enough for the offset of the odd rows to show.
+func (w *Window) drawBoard(src, dst sdl.Rect) {
+	if !w.hexagonal || w.view.zoom < 2 {
+		util.Check(w.renderer.Copy(w.texture, &src, &dst))
+		return
+	}
+	for y := src.Y; y < src.Y+src.H; y++ {
+		top := dst.Y + int32(math.Round(float64(y-src.Y)*w.view.zoom))
+		bottom := dst.Y + int32(math.Round(float64(y+1-src.Y)*w.view.zoom))
+		row := sdl.Rect{X: src.X, Y: y, W: src.W, H: 1}
+		rowDst := sdl.Rect{X: dst.X + w.rowOffset(int(y)), Y: top, W: dst.W, H: bottom - top}
+		util.Check(w.renderer.Copy(w.texture, &row, &rowDst))
+	}
+}
+
+// rowOffset gives how many pixels row y is drawn to the right, half a cell for the odd rows of hexagonal boards.
+func (w *Window) rowOffset(y int) int32 {
+	if !w.hexagonal || y%2 == 0 || w.view.zoom < 2 {
+		return 0
+	}
+	return int32(w.view.zoom / 2)
+}
+
+// SetAnts sets the cells where the ants of a turmite are drawn from the next frame on.
+func (w *Window) SetAnts(ants []gol.Ant) {
+	w.ants = w.ants[:0]
+	for _, ant := range ants {
+		w.ants = append(w.ants, sdl.Rect{X: int32(ant.X), Y: int32(ant.Y), W: 1, H: 1})
+	}
+}
+
+// drawAnts draws every ant as a red cell, at least one pixel wide so that it shows when zoomed out.
+func (w *Window) drawAnts() {
+	err := w.renderer.SetDrawColor(0xFF, 0x20, 0x20, 0xFF)
+	util.Check(err)
+	size := int32(math.Max(1, math.Round(w.view.zoom)))
+	rects := make([]sdl.Rect, 0, len(w.ants))
+	for _, ant := range w.ants {
+		rects = append(rects, sdl.Rect{
+			X: int32(math.Round((float64(ant.X)-w.view.x)*w.view.zoom)) + w.rowOffset(int(ant.Y)),
+			Y: int32(math.Round((float64(ant.Y) - w.view.y) * w.view.zoom)),
+			W: size,
+			H: size,
+		})
+	}
+	util.Check(w.renderer.FillRects(rects))
+}
+
+// drawSelection outlines the selected cells.
+func (w *Window) drawSelection() {
+	err := w.renderer.SetDrawColor(0xFF, 0xC0, 0x00, 0xFF)
+	util.Check(err)
+	outline := sdl.Rect{
+		X: int32(math.Round((float64(w.selection.X) - w.view.x) * w.view.zoom)),
+		Y: int32(math.Round((float64(w.selection.Y) - w.view.y) * w.view.zoom)),
+		W: int32(math.Max(1, math.Round(float64(w.selection.W)*w.view.zoom))),
+		H: int32(math.Max(1, math.Round(float64(w.selection.H)*w.view.zoom))),
+	}
+	util.Check(w.renderer.DrawRect(&outline))
+}
+
+// drawGrid draws a line along every cell edge inside dst, which shows the cells in src.
+func (w *Window) drawGrid(src, dst sdl.Rect) {
+	err := w.renderer.SetDrawColor(0x40, 0x40, 0x40, 0xFF)
+	util.Check(err)
+	if w.hexagonal {
+		//the edges between the cells of a row move with the row
+		for y := src.Y; y < src.Y+src.H; y++ {
+			top := dst.Y + int32(float64(y-src.Y)*w.view.zoom)
+			bottom := dst.Y + int32(float64(y+1-src.Y)*w.view.zoom)
+			for x := src.X; x <= src.X+src.W; x++ {
+				screenX := dst.X + int32(float64(x-src.X)*w.view.zoom) + w.rowOffset(int(y))
+				util.Check(w.renderer.DrawLine(screenX, top, screenX, bottom))
+			}
+		}
+	} else {
+		for x := src.X; x <= src.X+src.W; x++ {
+			screenX := dst.X + int32(float64(x-src.X)*w.view.zoom)
+			util.Check(w.renderer.DrawLine(screenX, dst.Y, screenX, dst.Y+dst.H))
+		}
+	}
+	for y := src.Y; y <= src.Y+src.H; y++ {
+		screenY := dst.Y + int32(float64(y-src.Y)*w.view.zoom)
+		util.Check(w.renderer.DrawLine(dst.X, screenY, dst.X+dst.W, screenY))
+	}
+}
+
+// markDirty records that row y has to be uploaded to the texture on the next frame.
+func (w *Window) markDirty(y int) {
+	if y < w.dirtyMin {
+		w.dirtyMin = y
+	}
+	if y > w.dirtyMax {
+		w.dirtyMax = y
+	}
+}
+
 func (w *Window) PollEvent() sdl.Event {
 	return sdl.PollEvent()
 }
 
 func (w *Window) SetPixel(x, y int) {
 	width := int(w.Width)
+	if !w.cells[y*width+x] {
+		w.alive++
+	}
+	w.cells[y*width+x] = true
 	w.pixels[4*(y*width+x)+0] = 0xFF
 	w.pixels[4*(y*width+x)+1] = 0xFF
 	w.pixels[4*(y*width+x)+2] = 0xFF
 	w.pixels[4*(y*width+x)+3] = 0xFF
+	w.markDirty(y)
 }
 
 func (w *Window) FlipPixel(x, y int) {
@@ -81,24 +254,53 @@ func (w *Window) FlipPixel(x, y int) {
 	}
 
 	width := int(w.Width)
-	w.pixels[4*(y*width+x)+0] = ^w.pixels[4*(y*width+x)+0]
-	w.pixels[4*(y*width+x)+1] = ^w.pixels[4*(y*width+x)+1]
-	w.pixels[4*(y*width+x)+2] = ^w.pixels[4*(y*width+x)+2]
-	w.pixels[4*(y*width+x)+3] = ^w.pixels[4*(y*width+x)+3]
+	w.cells[y*width+x] = !w.cells[y*width+x]
+	if w.cells[y*width+x] {
+		w.alive++
+	} else {
+		w.alive--
+	}
+	w.history.flip(y*width + x)
+	if w.history.mode != Binary || w.states != nil {
+		return
+	}
+	var value byte
+	if w.cells[y*width+x] {
+		value = 0xFF
+	}
+	w.pixels[4*(y*width+x)+0] = value
+	w.pixels[4*(y*width+x)+1] = value
+	w.pixels[4*(y*width+x)+2] = value
+	w.pixels[4*(y*width+x)+3] = value
+	w.markDirty(y)
+}
+
+// Alive tells whether cell x, y is alive.
+func (w *Window) Alive(x, y int) bool {
+	return w.cells[y*int(w.Width)+x]
 }
 
 func (w *Window) CountPixels() int {
-	count := 0
-	for i := 0; i < int(w.Width)*int(w.Height)*4; i += 4 {
-		if w.pixels[i] == 0xFF {
-			count++
-		}
-	}
-	return count
+	return w.alive
+}
+
+// SetHUD sets the lines of text drawn over the top-left corner of the board from the next frame on.
+// No lines hide the HUD.
+func (w *Window) SetHUD(lines []string) {
+	w.hud = lines
 }
 
 func (w *Window) ClearPixels() {
 	for i := range w.pixels {
 		w.pixels[i] = 0
 	}
+	for i := range w.cells {
+		w.cells[i] = false
+	}
+	w.alive = 0
+	for i := range w.states {
+		w.states[i] = 0
+	}
+	w.history.reset()
+	w.dirtyMin, w.dirtyMax = 0, int(w.Height)-1
 }
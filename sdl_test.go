@@ -3,6 +3,7 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"runtime"
 	"testing"
@@ -10,6 +11,7 @@ import (
 
 	"uk.ac.bris.cs/gameoflife/gol"
 	"uk.ac.bris.cs/gameoflife/sdl"
+	"uk.ac.bris.cs/gameoflife/util"
 )
 
 var sdlEvents chan gol.Event
@@ -20,6 +22,9 @@ func TestMain(m *testing.M) {
 	noVis := flag.Bool("noVis", false,
 		"Disables the SDL window, so there is no visualisation during the tests.")
 	flag.Parse()
+	dir, err := ioutil.TempDir("", "gol")
+	util.Check(err)
+	testOut = dir
 	p := gol.Params{ImageWidth: 512, ImageHeight: 512}
 	sdlEvents = make(chan gol.Event)
 	sdlAlive = make(chan int)
@@ -86,7 +91,9 @@ sdlLoop:
 			break
 		}
 	}
-	os.Exit(<-result)
+	code := <-result
+	_ = os.RemoveAll(testOut)
+	os.Exit(code)
 }
 
 // TestSdl tests a 512x512 image for 100 turns using 8 worker threads.
@@ -97,6 +104,7 @@ func TestSdl(t *testing.T) {
 	t.Run(testName, func(t *testing.T) {
 		turnNum := 0
 		events := make(chan gol.Event)
+		p.OutDir = testOut
 		go gol.Run(p, events, nil)
 		time.Sleep(2 * time.Second)
 		final := false
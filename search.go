@@ -0,0 +1,113 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+)
+
+// search is the function called when starting a soup search with 'go run . search'
+func search(args []string) {
+	var params gol.Params
+	var searchParams gol.SearchParams
+	flags := flag.NewFlagSet("search", flag.ExitOnError)
+
+	flags.IntVar(
+		&params.Threads,
+		"t",
+		8,
+		"Specify the number of soups to run at the same time, or 0 for one per CPU. Defaults to 8.")
+
+	flags.IntVar(
+		&params.ImageWidth,
+		"w",
+		64,
+		"Specify the width of the board each soup runs on. Defaults to 64.")
+
+	flags.IntVar(
+		&params.ImageHeight,
+		"h",
+		64,
+		"Specify the height of the board each soup runs on. Defaults to 64.")
+
+	flags.StringVar(
+		&params.Rule,
+		"rule",
+		"B3/S23",
+		"Specify the rule in B/S notation. Defaults to B3/S23, Conway's Game of Life.")
+
+	flags.Int64Var(
+		&searchParams.Seed,
+		"seed",
+		time.Now().UnixNano(),
+		"Specify the seed of the first soup. Defaults to the current time.")
+
+	flags.IntVar(
+		&searchParams.Soups,
+		"soups",
+		10000,
+		"Specify the number of soups to search. Defaults to 10000.")
+
+	flags.IntVar(
+		&searchParams.SoupSize,
+		"size",
+		16,
+		"Specify the side of the random square in the middle of the board. Defaults to 16.")
+
+	flags.Float64Var(
+		&searchParams.Density,
+		"density",
+		0.5,
+		"Specify the chance of each cell in the soup being alive. Defaults to 0.5.")
+
+	flags.StringVar(
+		&searchParams.Symmetry,
+		"sym",
+		"C1",
+		"Specify the symmetry of the soups: C1, C2, C4 or D8. Defaults to C1, no symmetry.")
+
+	flags.IntVar(
+		&searchParams.MaxTurns,
+		"maxTurns",
+		10000,
+		"Specify the number of turns after which an unsettled soup is given up on. Defaults to 10000.")
+
+	flags.StringVar(
+		&searchParams.Log,
+		"log",
+		"",
+		"Specify the file the rare objects are logged to. Defaults to out/search_<seed>.log.")
+
+	_ = flags.Parse(args)
+
+	fmt.Println("Threads:", params.Threads)
+	fmt.Println("Width:", params.ImageWidth)
+	fmt.Println("Height:", params.ImageHeight)
+	fmt.Println("Seed:", searchParams.Seed)
+	fmt.Println("Symmetry:", searchParams.Symmetry)
+
+	result := gol.Search(params, searchParams)
+
+	codes := make([]string, 0, len(result.Census))
+	for code := range result.Census {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool {
+		if result.Census[codes[i]] != result.Census[codes[j]] {
+			return result.Census[codes[i]] > result.Census[codes[j]]
+		}
+		return codes[i] < codes[j]
+	})
+	fmt.Printf("Census of %d soups (%d did not settle):\n", result.Soups, result.Unfinished)
+	for _, code := range codes {
+		fmt.Printf("%10d  %-24v%v\n", result.Census[code], code, gol.CommonName(code))
+	}
+	log := searchParams.Log
+	if log == "" {
+		log = fmt.Sprintf("out/search_%d.log", searchParams.Seed)
+	}
+	fmt.Printf("%d rare objects, see %v\n", len(result.Rare), log)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestSearch checks that a soup search finds the same objects no matter how many worker threads are used,
+// including one per CPU with 0 threads.
+func TestSearch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "search")
+	util.Check(err)
+	defer os.RemoveAll(dir)
+	s := gol.SearchParams{Seed: 1, Soups: 50, SoupSize: 16, Density: 0.5, MaxTurns: 5000,
+		Log: filepath.Join(dir, "search.log")}
+	var expected gol.SearchResult
+	for _, threads := range []int{1, 4, 16, 0} {
+		p := gol.Params{Threads: threads, ImageWidth: 64, ImageHeight: 64}
+		t.Run(fmt.Sprintf("%dx%d-%d", p.ImageWidth, p.ImageHeight, p.Threads), func(t *testing.T) {
+			result := gol.Search(p, s)
+			if result.Soups != s.Soups {
+				t.Fatalf("Expected %d soups to be searched, got %d", s.Soups, result.Soups)
+			}
+			if result.Census["xs4_33"] == 0 {
+				t.Error("Expected the census to contain at least one block (xs4_33)")
+			}
+			if threads == 1 {
+				expected = result
+			} else if !reflect.DeepEqual(result, expected) {
+				t.Errorf("Census with %d threads differs from the census with 1 thread:\n%v\n%v", threads, result, expected)
+			}
+		})
+	}
+}
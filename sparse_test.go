@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestSparse runs small soups on a board that is not a whole number of tiles, so that most of it settles
+// and only the tiles around the changes are recomputed, and checks the runs against the reference
+// implementation. It then paints a blinker into a board that has long settled.
+func TestSparse(t *testing.T) {
+	for _, rulestring := range []string{"B3/S23", "B2/S/C3"} {
+		for _, threads := range []int{1, 7, 16} {
+			p := gol.Params{Turns: 150, Threads: threads, ImageWidth: 100, ImageHeight: 70, Rule: rulestring,
+				Generator: gol.Generator{Kind: "box", Seed: 3, Density: 0.4, Size: 16}}
+			t.Run(fmt.Sprintf("%v-%d", rulestring, threads), func(t *testing.T) {
+				if rulestring == "B3/S23" {
+					testSparseLife(t, p)
+				} else {
+					testGenerations(t, p)
+				}
+			})
+		}
+	}
+
+	t.Run("edit", func(t *testing.T) {
+		p := gol.Params{Turns: 100000000, Threads: 4, ImageWidth: 100, ImageHeight: 70,
+			Generator: gol.Generator{Kind: "box", Density: 0}}
+		events := make(chan gol.Event)
+		keyPresses := make(chan rune, 10)
+		edits := make(chan gol.Edit)
+		p.OutDir = testOut
+		go gol.RunWithEdits(p, events, keyPresses, edits)
+
+		board := make(map[util.Cell]bool)
+		flips := 0
+		timeout := time.After(10 * time.Second)
+		next := func() gol.Event {
+			for {
+				select {
+				case event := <-events:
+					if e, ok := event.(gol.CellFlipped); ok {
+						board[e.Cell] = !board[e.Cell]
+						if e.Cell == (util.Cell{X: 49, Y: 35}) {
+							flips++
+						}
+						continue
+					}
+					return event
+				case <-timeout:
+					t.Fatal("No events for 10s")
+				}
+			}
+		}
+		waitTurn := func(turn int) {
+			for {
+				if e, ok := next().(gol.TurnComplete); ok && e.CompletedTurns >= turn {
+					return
+				}
+			}
+		}
+
+		//by now every tile of the empty board has settled
+		waitTurn(3)
+		go func() {
+			for y := 34; y <= 36; y++ {
+				edits <- gol.Edit{Kind: gol.EditSet, Cell: util.Cell{X: 50, Y: y}, Alive: true}
+			}
+		}()
+		for edited := 0; edited < 3; {
+			if _, ok := next().(gol.WorldEdited); ok {
+				edited++
+			}
+		}
+		start := 0
+		for {
+			if e, ok := next().(gol.TurnComplete); ok {
+				start = e.CompletedTurns
+				break
+			}
+		}
+		waitTurn(start + 6)
+		keyPresses <- 'q'
+		var final gol.FinalTurnComplete
+		for {
+			if e, ok := next().(gol.FinalTurnComplete); ok {
+				final = e
+				break
+			}
+		}
+		go func() {
+			for range events {
+			}
+		}()
+
+		vertical := []util.Cell{{X: 50, Y: 34}, {X: 50, Y: 35}, {X: 50, Y: 36}}
+		horizontal := []util.Cell{{X: 49, Y: 35}, {X: 50, Y: 35}, {X: 51, Y: 35}}
+		if flips < 5 {
+			t.Fatalf("Expected the blinker to oscillate every turn, its side cells flipped %d times in 6 turns", flips)
+		}
+		expected := vertical
+		if flips%2 == 1 {
+			expected = horizontal
+		}
+		assertEqualBoard(t, final.Alive, expected, p)
+		var flipped []util.Cell
+		for cell, alive := range board {
+			if alive {
+				flipped = append(flipped, cell)
+			}
+		}
+		assertEqualBoard(t, flipped, expected, p)
+	})
+}
+
+// testSparseLife checks a run of a rule with two states, which only sends CellFlipped events, against
+// referenceGenerations.
+func testSparseLife(t *testing.T, p gol.Params) {
+	rule, _ := gol.ParseRule(p.Rule)
+	initial := states0(p)
+	events := make(chan gol.Event)
+	p.OutDir = testOut
+	go gol.Run(p, events, nil)
+	board := make(map[util.Cell]bool)
+	var final gol.FinalTurnComplete
+	for event := range events {
+		switch e := event.(type) {
+		case gol.CellFlipped:
+			board[e.Cell] = !board[e.Cell]
+		case gol.FinalTurnComplete:
+			final = e
+		}
+	}
+
+	var expected, flipped []util.Cell
+	for y, row := range referenceGenerations(rule, initial, p.Turns) {
+		for x, state := range row {
+			if state == 1 {
+				expected = append(expected, util.Cell{X: x, Y: y})
+			}
+		}
+	}
+	for cell, alive := range board {
+		if alive {
+			flipped = append(flipped, cell)
+		}
+	}
+	assertEqualBoard(t, final.Alive, expected, p)
+	assertEqualBoard(t, flipped, expected, p)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestStats checks the TurnStats events of 100 turns on a 64x64 image against check/alive and the stats file.
+func TestStats(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stats")
+	util.Check(err)
+	defer os.RemoveAll(dir)
+
+	p := gol.Params{Turns: 100, Threads: 4, ImageWidth: 64, ImageHeight: 64,
+		Stats: true, StatsFile: filepath.Join(dir, "stats.csv")}
+	alive := readAliveCounts(p.ImageWidth, p.ImageHeight)
+	events := make(chan gol.Event)
+	p.OutDir = testOut
+	go gol.Run(p, events, nil)
+
+	var received []gol.TurnStats
+	board := make(map[util.Cell]bool)
+	for event := range events {
+		switch e := event.(type) {
+		case gol.CellFlipped:
+			board[e.Cell] = !board[e.Cell]
+		case gol.TurnStats:
+			population := 0
+			for _, cellAlive := range board {
+				if cellAlive {
+					population++
+				}
+			}
+			if e.Population != alive[e.CompletedTurns] || e.Population != population {
+				t.Errorf("At turn %v expected %v alive cells, got %v instead", e.CompletedTurns, alive[e.CompletedTurns], e.Population)
+			}
+			if len(received) > 0 {
+				previous := received[len(received)-1].Population
+				if previous+e.Births-e.Deaths != e.Population {
+					t.Errorf("At turn %v %v births and %v deaths do not take %v alive cells to %v",
+						e.CompletedTurns, e.Births, e.Deaths, previous, e.Population)
+				}
+			}
+			if e.Population > 0 && (e.Min.X > e.Max.X || e.Min.Y > e.Max.Y || e.Max.X >= 64 || e.Max.Y >= 64) {
+				t.Errorf("At turn %v bounding box %v-%v is not valid", e.CompletedTurns, e.Min, e.Max)
+			}
+			received = append(received, e)
+		}
+	}
+	if len(received) != p.Turns {
+		t.Fatalf("Expected %d TurnStats events, got %d", p.Turns, len(received))
+	}
+
+	f, err := os.Open(p.StatsFile)
+	util.Check(err)
+	defer f.Close()
+	table, err := csv.NewReader(f).ReadAll()
+	util.Check(err)
+	if len(table) != p.Turns+1 {
+		t.Fatalf("Expected a header and %d rows in %v, got %d lines", p.Turns, p.StatsFile, len(table))
+	}
+	for i, row := range table[1:] {
+		turn, _ := strconv.Atoi(row[0])
+		population, _ := strconv.Atoi(row[1])
+		if turn != received[i].CompletedTurns || population != received[i].Population {
+			t.Errorf("Row %v of the stats file is %v, expected turn %v with %v alive cells",
+				i+1, row, received[i].CompletedTurns, received[i].Population)
+		}
+	}
+
+	//the stats file of every run is closed once the run has ended, on either engine
+	t.Run("closed", func(t *testing.T) {
+		before, err := ioutil.ReadDir("/proc/self/fd")
+		if err != nil {
+			t.Skip("The open files cannot be counted without /proc")
+		}
+		for i := 0; i < 10; i++ {
+			for _, engine := range []string{"channels", "shared"} {
+				p := gol.Params{Turns: 5, Threads: 2, ImageWidth: 16, ImageHeight: 16, Engine: engine,
+					Stats: true, StatsFile: filepath.Join(dir, fmt.Sprintf("%v%d.csv", engine, i))}
+				events := make(chan gol.Event)
+				p.OutDir = testOut
+				go gol.Run(p, events, nil)
+				for range events {
+				}
+			}
+		}
+		after, err := ioutil.ReadDir("/proc/self/fd")
+		util.Check(err)
+		if len(after) > len(before) {
+			t.Errorf("Expected the stats files to be closed, %d more files are open after 20 runs", len(after)-len(before))
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package term
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Key is a key pressed in the terminal, either a rune or one of the arrow keys below.
+type Key rune
+
+const (
+	KeyUp Key = -1 - iota
+	KeyDown
+	KeyRight
+	KeyLeft
+	KeyHome
+)
+
+// stty runs stty on the terminal attached to stdin.
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	output, err := cmd.Output()
+	return strings.TrimSpace(string(output)), err
+}
+
+// startInput switches the terminal to reading single key presses without echoing them, and sends every key
+// on the returned channel. The returned function puts the terminal back the way it was.
+// Where stty is not available keys still arrive, but only after Enter is pressed.
+func startInput() (<-chan Key, func()) {
+	saved, err := stty("-g")
+	restore := func() {}
+	if err == nil {
+		if _, err = stty("-icanon", "-echo", "min", "1"); err == nil {
+			restore = func() { _, _ = stty(saved) }
+		}
+	}
+
+	keys := make(chan Key, 10)
+	go readKeys(os.Stdin, keys)
+	return keys, restore
+}
+
+// readKeys sends the keys read from in to keys until in ends, turning the escape sequences of the arrow keys
+// and Home into their Keys.
+func readKeys(in io.Reader, keys chan<- Key) {
+	reader := bufio.NewReader(in)
+	for {
+		r, _, err := reader.ReadRune()
+		if err != nil {
+			return
+		}
+		// Arrow keys arrive as the escape sequences ESC [ A to ESC [ D.
+		if r == 0x1b {
+			if next, _, err := reader.ReadRune(); err != nil || next != '[' {
+				continue
+			}
+			code, _, err := reader.ReadRune()
+			if err != nil {
+				return
+			}
+			switch code {
+			case 'A':
+				keys <- KeyUp
+			case 'B':
+				keys <- KeyDown
+			case 'C':
+				keys <- KeyRight
+			case 'D':
+				keys <- KeyLeft
+			case 'H':
+				keys <- KeyHome
+			}
+			continue
+		}
+		keys <- Key(r)
+	}
+}
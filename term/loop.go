@@ -0,0 +1,86 @@
+package term
+
+import (
+	"fmt"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+)
+
+// frameInterval limits how often the terminal is redrawn, as drawing is much slower than a turn.
+const frameInterval = 50 * time.Millisecond
+
+// Run shows the Game of Life in the terminal instead of an SDL window.
+// p, s, q and k are sent to keyPresses like in sdl.Run, the arrow keys scroll the viewport,
+// Home goes back to the top-left corner and g switches between half blocks, quadrants and braille.
+func Run(p gol.Params, events <-chan gol.Event, keyPresses chan<- rune) {
+	keys, restore := startInput()
+	defer restore()
+	s := NewScreen(p.ImageWidth, p.ImageHeight)
+	defer s.Destroy()
+
+	turn := 0
+	state := gol.Executing.String()
+	message := ""
+	lastFrame := time.Time{}
+	lastResize := time.Now()
+	dirty := true
+	ticker := time.NewTicker(frameInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case key := <-keys:
+			viewWidth, viewHeight := s.View()
+			switch key {
+			case 'p', 's', 'q', 'k':
+				keyPresses <- rune(key)
+			case 'g':
+				s.NextGlyphs()
+			case KeyUp:
+				s.Scroll(0, -viewHeight/4)
+			case KeyDown:
+				s.Scroll(0, viewHeight/4)
+			case KeyLeft:
+				s.Scroll(-viewWidth/4, 0)
+			case KeyRight:
+				s.Scroll(viewWidth/4, 0)
+			case KeyHome:
+				s.Scroll(-s.ViewX, -s.ViewY)
+			}
+			dirty = true
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			switch e := event.(type) {
+			case gol.CellFlipped:
+				//the unbounded plane is only shown where the board was
+				if !p.Unbounded || (e.Cell.X >= 0 && e.Cell.Y >= 0 && e.Cell.X < p.ImageWidth && e.Cell.Y < p.ImageHeight) {
+					s.FlipPixel(e.Cell.X, e.Cell.Y)
+				}
+			case gol.TurnComplete:
+				turn = e.CompletedTurns
+				dirty = true
+			case gol.StateChange:
+				state = e.NewState.String()
+			case gol.FinalTurnComplete:
+				return
+			default:
+				if len(event.String()) > 0 {
+					message = fmt.Sprintf("Completed Turns %-8v%v", event.GetCompletedTurns(), event)
+				}
+			}
+		case <-ticker.C:
+		}
+		if time.Since(lastResize) > time.Second {
+			s.Resize()
+			lastResize = time.Now()
+		}
+		if dirty && time.Since(lastFrame) > frameInterval {
+			s.RenderFrame(fmt.Sprintf("Turn %d  Alive %d  %s  %s", turn, s.CountPixels(), state, message))
+			lastFrame = time.Now()
+			dirty = false
+		}
+	}
+}
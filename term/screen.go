@@ -0,0 +1,207 @@
+package term
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// Glyphs selects how many cells are packed into each character on the terminal.
+type Glyphs int
+
+const (
+	HalfBlocks Glyphs = iota // 1x2 cells per character: ▀ ▄ █
+	Quadrants                // 2x2 cells per character: ▘ ▚ ▙ ...
+	Braille                  // 2x4 cells per character: ⠁ ⠇ ⣿ ...
+)
+
+var quadrants = []rune(" ▘▝▀▖▌▞▛▗▚▐▜▄▙▟█")
+
+func (g Glyphs) String() string {
+	switch g {
+	case HalfBlocks:
+		return "half blocks"
+	case Quadrants:
+		return "quadrants"
+	case Braille:
+		return "braille"
+	default:
+		return "Incorrect Glyphs"
+	}
+}
+
+// size gives the number of cells across and down that one character shows.
+func (g Glyphs) size() (int, int) {
+	switch g {
+	case Quadrants:
+		return 2, 2
+	case Braille:
+		return 2, 4
+	default:
+		return 1, 2
+	}
+}
+
+// Screen draws the board on an ANSI terminal, the same way Window draws it in SDL.
+// Only the part of the board inside the viewport is drawn, starting at cell (ViewX, ViewY).
+type Screen struct {
+	Width, Height int
+	Glyphs        Glyphs
+	ViewX, ViewY  int
+	cells         []bool
+	columns, rows int
+	out           *bufio.Writer
+}
+
+func NewScreen(width, height int) *Screen {
+	s := &Screen{
+		Width:  width,
+		Height: height,
+		cells:  make([]bool, width*height),
+		out:    bufio.NewWriterSize(os.Stdout, 1<<16),
+	}
+	s.Resize()
+	// Switch to the alternate screen and hide the cursor.
+	_, _ = s.out.WriteString("\x1b[?1049h\x1b[?25l\x1b[2J")
+	return s
+}
+
+// Resize asks the terminal for its size, falling back to 80x24 if it cannot tell.
+func (s *Screen) Resize() {
+	s.columns, s.rows = 80, 24
+	cmd := exec.Command("stty", "size")
+	cmd.Stdin = os.Stdin
+	output, err := cmd.Output()
+	if err != nil {
+		return
+	}
+	fields := strings.Fields(string(output))
+	if len(fields) == 2 {
+		rows, errRows := strconv.Atoi(fields[0])
+		columns, errColumns := strconv.Atoi(fields[1])
+		if errRows == nil && errColumns == nil && rows > 4 && columns > 4 {
+			s.rows, s.columns = rows, columns
+		}
+	}
+	s.Scroll(0, 0)
+}
+
+func (s *Screen) Destroy() {
+	// Show the cursor again and leave the alternate screen.
+	_, _ = s.out.WriteString("\x1b[?25h\x1b[?1049l")
+	util.Check(s.out.Flush())
+}
+
+// View gives the number of cells across and down that fit inside the border.
+func (s *Screen) View() (int, int) {
+	glyphWidth, glyphHeight := s.Glyphs.size()
+	// one row is used by the status line, two rows and two columns by the border
+	return (s.columns - 2) * glyphWidth, (s.rows - 3) * glyphHeight
+}
+
+// Scroll moves the viewport by dx, dy cells, keeping it inside the board.
+func (s *Screen) Scroll(dx, dy int) {
+	viewWidth, viewHeight := s.View()
+	s.ViewX = clamp(s.ViewX+dx, 0, s.Width-viewWidth)
+	s.ViewY = clamp(s.ViewY+dy, 0, s.Height-viewHeight)
+}
+
+func clamp(value, min, max int) int {
+	if value > max {
+		value = max
+	}
+	if value < min {
+		value = min
+	}
+	return value
+}
+
+// NextGlyphs switches to the next way of packing cells into characters.
+func (s *Screen) NextGlyphs() {
+	s.Glyphs = (s.Glyphs + 1) % (Braille + 1)
+	s.Scroll(0, 0)
+}
+
+func (s *Screen) FlipPixel(x, y int) {
+	if x < 0 || y < 0 || x >= s.Width || y >= s.Height {
+		panic(fmt.Sprintf("CellFlipped event at (%d, %d) is outside the bounds of the screen.", x, y))
+	}
+	s.cells[y*s.Width+x] = !s.cells[y*s.Width+x]
+}
+
+func (s *Screen) CountPixels() int {
+	count := 0
+	for _, alive := range s.cells {
+		if alive {
+			count++
+		}
+	}
+	return count
+}
+
+func (s *Screen) ClearPixels() {
+	for i := range s.cells {
+		s.cells[i] = false
+	}
+}
+
+// alive tells whether a cell is alive, treating cells outside the board as dead.
+func (s *Screen) alive(x, y int) bool {
+	return x < s.Width && y < s.Height && s.cells[y*s.Width+x]
+}
+
+// glyph packs the cells starting at x, y into one character.
+func (s *Screen) glyph(x, y int) rune {
+	bit := func(dx, dy int) int {
+		if s.alive(x+dx, y+dy) {
+			return 1
+		}
+		return 0
+	}
+	switch s.Glyphs {
+	case Quadrants:
+		return quadrants[bit(0, 0)|bit(1, 0)<<1|bit(0, 1)<<2|bit(1, 1)<<3]
+	case Braille:
+		dots := bit(0, 0) | bit(0, 1)<<1 | bit(0, 2)<<2 | bit(1, 0)<<3 |
+			bit(1, 1)<<4 | bit(1, 2)<<5 | bit(0, 3)<<6 | bit(1, 3)<<7
+		return rune(0x2800 + dots)
+	default:
+		return []rune(" ▀▄█")[bit(0, 0)|bit(0, 1)<<1]
+	}
+}
+
+// RenderFrame redraws the status line and the part of the board inside the viewport.
+func (s *Screen) RenderFrame(status string) {
+	glyphWidth, glyphHeight := s.Glyphs.size()
+	viewWidth, viewHeight := s.View()
+	if viewWidth > s.Width {
+		viewWidth = s.Width
+	}
+	if viewHeight > s.Height {
+		viewHeight = s.Height
+	}
+	columns := (viewWidth + glyphWidth - 1) / glyphWidth
+	rows := (viewHeight + glyphHeight - 1) / glyphHeight
+
+	_, _ = s.out.WriteString("\x1b[H")
+	status = fmt.Sprintf("%s  (%d,%d) %s", status, s.ViewX, s.ViewY, s.Glyphs)
+	if len([]rune(status)) > s.columns {
+		status = string([]rune(status)[:s.columns])
+	}
+	_, _ = s.out.WriteString(status + "\x1b[K\r\n")
+	_, _ = s.out.WriteString(util.HorizontalBorder("┌", "┐", columns) + "\x1b[K\r\n")
+	for row := 0; row < rows; row++ {
+		_, _ = s.out.WriteString("│")
+		for column := 0; column < columns; column++ {
+			_, _ = s.out.WriteRune(s.glyph(s.ViewX+column*glyphWidth, s.ViewY+row*glyphHeight))
+		}
+		_, _ = s.out.WriteString("│\x1b[K\r\n")
+	}
+	_, _ = s.out.WriteString(util.HorizontalBorder("└", "┘", columns) + "\x1b[K\x1b[J")
+	util.Check(s.out.Flush())
+}
@@ -0,0 +1,125 @@
+package term
+
+import (
+	"bufio"
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testScreen makes a Screen for a width x height board on a terminal of columns x rows, which draws into
+// output instead of the terminal.
+func testScreen(width, height, columns, rows int, output *bytes.Buffer) *Screen {
+	return &Screen{
+		Width:   width,
+		Height:  height,
+		cells:   make([]bool, width*height),
+		columns: columns,
+		rows:    rows,
+		out:     bufio.NewWriter(output),
+	}
+}
+
+// TestReadKeys checks that runes are passed on as they are, and that the escape sequences of the arrow keys
+// and Home become their Keys.
+func TestReadKeys(t *testing.T) {
+	keys := make(chan Key, 100)
+	readKeys(strings.NewReader("ps\x1b[A\x1b[B\x1b[C\x1b[D\x1b[H\x1bxé\x1b[Zq"), keys)
+	close(keys)
+	var read []Key
+	for key := range keys {
+		read = append(read, key)
+	}
+	// an escape that does not start a sequence is dropped with the rune after it, as is an unknown sequence
+	expected := []Key{'p', 's', KeyUp, KeyDown, KeyRight, KeyLeft, KeyHome, 'é', 'q'}
+	if !reflect.DeepEqual(read, expected) {
+		t.Errorf("Expected keys %v, got %v", expected, read)
+	}
+}
+
+// TestGlyph checks the character that every way of packing cells gives, with the cells past the edge of
+// the board taken as dead.
+func TestGlyph(t *testing.T) {
+	s := testScreen(3, 5, 80, 24, &bytes.Buffer{})
+	for _, cell := range [][2]int{{0, 0}, {1, 1}, {2, 0}, {2, 1}, {0, 3}} {
+		s.FlipPixel(cell[0], cell[1])
+	}
+	tests := []struct {
+		glyphs   Glyphs
+		x, y     int
+		expected rune
+	}{
+		{HalfBlocks, 0, 0, '▀'},
+		{HalfBlocks, 1, 0, '▄'},
+		{HalfBlocks, 2, 0, '█'},
+		{HalfBlocks, 0, 4, ' '},
+		{Quadrants, 0, 0, '▚'},
+		{Quadrants, 2, 0, '▌'},
+		{Quadrants, 0, 2, '▖'},
+		{Braille, 0, 0, rune(0x2800 + 0x01 + 0x10 + 0x40)},
+		{Braille, 2, 0, '⠃'},
+		{Braille, 0, 4, rune(0x2800)},
+	}
+	for _, test := range tests {
+		s.Glyphs = test.glyphs
+		if glyph := s.glyph(test.x, test.y); glyph != test.expected {
+			t.Errorf("Expected %q at (%d, %d) with %v, got %q", test.expected, test.x, test.y, test.glyphs, glyph)
+		}
+	}
+}
+
+// TestScroll checks that the viewport stays inside the board as it is scrolled and as the glyphs change
+// how much of the board fits on the terminal.
+func TestScroll(t *testing.T) {
+	s := testScreen(100, 50, 42, 23, &bytes.Buffer{})
+	if width, height := s.View(); width != 40 || height != 40 {
+		t.Fatalf("Expected a view of 40x40 cells with half blocks, got %dx%d", width, height)
+	}
+	tests := []struct {
+		dx, dy       int
+		viewX, viewY int
+	}{
+		{5, 3, 5, 3},
+		{1000, 1000, 60, 10},
+		{-10, -4, 50, 6},
+		{-1000, 0, 0, 6},
+	}
+	for _, test := range tests {
+		s.Scroll(test.dx, test.dy)
+		if s.ViewX != test.viewX || s.ViewY != test.viewY {
+			t.Errorf("Expected the view at (%d, %d) after scrolling by (%d, %d), got (%d, %d)",
+				test.viewX, test.viewY, test.dx, test.dy, s.ViewX, s.ViewY)
+		}
+	}
+
+	// braille shows 80x80 cells, more than the board is high
+	s.Scroll(1000, 0)
+	s.NextGlyphs()
+	s.NextGlyphs()
+	if s.Glyphs != Braille || s.ViewX != 20 || s.ViewY != 0 {
+		t.Errorf("Expected the view at (20, 0) with braille, got (%d, %d) with %v", s.ViewX, s.ViewY, s.Glyphs)
+	}
+	s.NextGlyphs()
+	if s.Glyphs != HalfBlocks {
+		t.Errorf("Expected the glyphs to go back to half blocks, got %v", s.Glyphs)
+	}
+}
+
+// TestRenderFrame checks the status line, the border and the rows of a frame of a board smaller than the
+// terminal.
+func TestRenderFrame(t *testing.T) {
+	var output bytes.Buffer
+	s := testScreen(4, 4, 80, 24, &output)
+	s.Glyphs = Quadrants
+	s.FlipPixel(0, 0)
+	s.FlipPixel(1, 1)
+	s.FlipPixel(3, 3)
+	s.RenderFrame("Turn 7")
+	frame := output.String()
+	for _, expected := range []string{"Turn 7  (0,0) quadrants", "┌──┐", "│▚ │", "│ ▗│", "└──┘"} {
+		if !strings.Contains(frame, expected) {
+			t.Errorf("Expected %q in the frame %q", expected, frame)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"runtime"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestAutoThreads runs with Params.Threads 0 and checks that the number of workers it picks is reported
+// after the turn that settled it, and that the board is the same as with a fixed number of workers.
+// The 16x16 run is long enough for the choice to be made again. Go runs on 8 CPUs for the test, so that
+// there are several numbers to choose from on any machine.
+func TestAutoThreads(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(8))
+	t.Run("512x512x100", func(t *testing.T) {
+		p := gol.Params{Turns: 100, ImageWidth: 512, ImageHeight: 512}
+		alive, chosen := runAutoThreads(t, p)
+		if len(chosen) == 0 {
+			t.Fatal("Expected a ThreadsChanged event within 100 turns")
+		}
+		for _, e := range chosen {
+			if e.Threads != 1 && e.Threads != 2 && e.Threads != 4 && e.Threads != 8 {
+				t.Errorf("Turn %d chose %d threads, expected 1, 2, 4 or 8", e.CompletedTurns, e.Threads)
+			}
+		}
+		assertEqualBoard(t, alive, readAliveCells("check/images/512x512x100.pgm", p.ImageWidth, p.ImageHeight), p)
+	})
+
+	t.Run("16x16x1200", func(t *testing.T) {
+		p := gol.Params{Turns: 1200, ImageWidth: 16, ImageHeight: 16,
+			Generator: gol.Generator{Kind: "random", Seed: 2, Density: 0.4}}
+		alive, chosen := runAutoThreads(t, p)
+		for _, e := range chosen {
+			if e.Threads != 1 && e.Threads != 2 && e.Threads != 4 && e.Threads != 8 {
+				t.Errorf("Turn %d chose %d threads, expected 1, 2, 4 or 8", e.CompletedTurns, e.Threads)
+			}
+		}
+		p.Threads = 3
+		var expected []util.Cell
+		events := make(chan gol.Event)
+		p.OutDir = testOut
+		go gol.Run(p, events, nil)
+		for event := range events {
+			if e, ok := event.(gol.FinalTurnComplete); ok {
+				expected = e.Alive
+			}
+		}
+		assertEqualBoard(t, alive, expected, p)
+	})
+}
+
+// runAutoThreads gives the final alive cells of a run and its ThreadsChanged events, checking that each
+// of them comes straight after the TurnComplete of its turn.
+func runAutoThreads(t *testing.T, p gol.Params) ([]util.Cell, []gol.ThreadsChanged) {
+	events := make(chan gol.Event)
+	p.OutDir = testOut
+	go gol.Run(p, events, nil)
+	var alive []util.Cell
+	var chosen []gol.ThreadsChanged
+	last := 0
+	for event := range events {
+		switch e := event.(type) {
+		case gol.TurnComplete:
+			last = e.CompletedTurns
+		case gol.ThreadsChanged:
+			if e.CompletedTurns != last {
+				t.Errorf("ThreadsChanged of turn %d came after TurnComplete of turn %d", e.CompletedTurns, last)
+			}
+			chosen = append(chosen, e)
+		case gol.FinalTurnComplete:
+			alive = e.Alive
+		}
+	}
+	return alive, chosen
+}
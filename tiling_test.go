@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestTiling splits the board into tiles of several shapes, including tiles that do not divide the board
+// and tiles larger than it, and checks that every thread count from 1 to 16 gives the same final board and
+// the same CellFlipped events as splitting it into strips.
+func TestTiling(t *testing.T) {
+	rules := []string{"B3/S23", "B2/S/C3", "R2,C0,M1,S2..5,B3..4,NM", "B2-a/S12", "B2/S34H", "B2/S013V"}
+	tiles := [][2]int{{16, 16}, {7, 5}, {50, 0}, {0, 9}, {200, 200}}
+	for _, rulestring := range rules {
+		p := gol.Params{Turns: 20, Threads: 4, ImageWidth: 50, ImageHeight: 40, Rule: rulestring,
+			Generator: gol.Generator{Kind: "random", Seed: 11, Density: 0.35}}
+		expectedAlive, expectedFlipped := runTiled(p)
+		for threads := 1; threads <= 16; threads++ {
+			for _, size := range tiles {
+				p.Threads, p.TileWidth, p.TileHeight = threads, size[0], size[1]
+				t.Run(fmt.Sprintf("%v-%d-%dx%d", rulestring, threads, size[0], size[1]), func(t *testing.T) {
+					alive, flipped := runTiled(p)
+					assertEqualBoard(t, alive, expectedAlive, p)
+					assertEqualBoard(t, flipped, expectedFlipped, p)
+				})
+			}
+		}
+	}
+
+	t.Run("noise", func(t *testing.T) {
+		p := gol.Params{Turns: 20, Threads: 3, ImageWidth: 50, ImageHeight: 40,
+			Generator: gol.Generator{Kind: "random", Seed: 11, Density: 0.35},
+			Noise:     gol.Noise{Birth: 0.9, Death: 0.02, Seed: 4}}
+		expected, _ := runTiled(p)
+		p.Threads, p.TileWidth, p.TileHeight = 16, 8, 8
+		alive, _ := runTiled(p)
+		assertEqualBoard(t, alive, expected, p)
+	})
+}
+
+// TestTilingRejected checks that turmites and Margolus rules, which do not step the world tile by tile, are
+// rejected when tiles are asked for instead of running as if they were not.
+func TestTilingRejected(t *testing.T) {
+	runs := map[string]gol.Params{
+		"turmite":  {Turmite: "RL"},
+		"margolus": {Rule: "Critters"},
+	}
+	for name, p := range runs {
+		p.Turns, p.Threads, p.ImageWidth, p.ImageHeight = 1, 2, 32, 32
+		if err := gol.Validate(p); err != nil {
+			t.Errorf("Expected the %v to run in strips, got %v", name, err)
+		}
+		p.TileWidth, p.TileHeight = 8, 8
+		if err := gol.Validate(p); err == nil {
+			t.Errorf("Expected the %v to be rejected with tiles", name)
+		}
+	}
+}
+
+// runTiled gives the final alive cells of a run, and the alive cells according to its CellFlipped events.
+func runTiled(p gol.Params) ([]util.Cell, []util.Cell) {
+	events := make(chan gol.Event)
+	p.OutDir = testOut
+	go gol.Run(p, events, nil)
+	board := make(map[util.Cell]bool)
+	var alive, flipped []util.Cell
+	for event := range events {
+		switch e := event.(type) {
+		case gol.CellFlipped:
+			board[e.Cell] = !board[e.Cell]
+		case gol.FinalTurnComplete:
+			alive = e.Alive
+		}
+	}
+	for cell, on := range board {
+		if on {
+			flipped = append(flipped, cell)
+		}
+	}
+	return alive, flipped
+}
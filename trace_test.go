@@ -2,21 +2,24 @@ package main
 
 import (
 	"os"
+	"path/filepath"
 	"runtime/trace"
 	"testing"
 	"uk.ac.bris.cs/gameoflife/gol"
 	"uk.ac.bris.cs/gameoflife/util"
 )
 
-// TestTrace is a special test to be used to generate traces - not a real test
+// TestTrace is a special test to be used to generate traces - not a real test.
+// The trace goes to the output directory of the tests, go test -run TestTrace -trace trace.out keeps one.
 func TestTrace(t *testing.T) {
 	traceParams := gol.Params{
 		Turns:       10,
 		Threads:     4,
 		ImageWidth:  64,
 		ImageHeight: 64,
+		OutDir:      testOut,
 	}
-	f, _ := os.Create("trace.out")
+	f, _ := os.Create(filepath.Join(testOut, "trace.out"))
 	events := make(chan gol.Event)
 	err := trace.Start(f)
 	util.Check(err)
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestTurmite runs Langton's ant and other turmites with many ants on 1-16 worker threads and checks the
+// board and the ants against a simple reference implementation.
+func TestTurmite(t *testing.T) {
+	for _, spec := range []string{"RL", "LLRR", "{{{1,2,0},{0,8,0}}}", "{{{1, 8, 1}, {1, 8, 1}}, {{1, 2, 1}, {0, 1, 0}}}"} {
+		if _, err := gol.ParseTurmite(spec); err != nil {
+			t.Errorf("ParseTurmite(%q) failed: %v", spec, err)
+		}
+	}
+	for _, spec := range []string{"", "R", "RX", "{{{2,2,0},{0,8,0}}}", "{{{1,3,0},{0,8,0}}}", "{{{1,2,1},{0,8,0}}}", "{{{1,2,0},{0,8}}}"} {
+		if _, err := gol.ParseTurmite(spec); err == nil {
+			t.Errorf("ParseTurmite(%q) should fail", spec)
+		}
+	}
+
+	t.Run("langton", func(t *testing.T) {
+		//on an empty board the ant turns right 4 times and comes back, leaving a 2x2 block
+		p := gol.Params{Turns: 4, Threads: 4, ImageWidth: 64, ImageHeight: 64, Turmite: "RL",
+			Generator: gol.Generator{Kind: "random", Density: 0}}
+		board, ants := runTurmite(p)
+		expected := []util.Cell{{X: 32, Y: 32}, {X: 33, Y: 32}, {X: 33, Y: 33}, {X: 32, Y: 33}}
+		assertEqualBoard(t, aliveColours(board), expected, p)
+		if ants[0] != (gol.Ant{X: 32, Y: 32, Direction: gol.North}) {
+			t.Errorf("Ant is %+v after 4 turns, expected back at (32, 32) facing north", ants[0])
+		}
+	})
+
+	//many ants, some of them on the same cell, crossing the strips of the workers and the edges of the board
+	var ants []gol.Ant
+	for i := 0; i < 40; i++ {
+		ants = append(ants, gol.Ant{X: (i * 37) % 64, Y: (i * 13) % 64, Direction: gol.Direction(i % 4)})
+	}
+	ants = append(ants, gol.Ant{X: 0, Y: 0, Direction: gol.East}, gol.Ant{X: 0, Y: 0, Direction: gol.South})
+	for _, spec := range []string{"RL", "{{{1,2,0},{0,8,0}}}", "LLRR", "RLR"} {
+		for _, threads := range []int{1, 3, 8, 16} {
+			p := gol.Params{Turns: 300, Threads: threads, ImageWidth: 64, ImageHeight: 64, Turmite: spec, Ants: ants,
+				Generator: gol.Generator{Kind: "random", Seed: 4, Density: 0.2}}
+			t.Run(fmt.Sprintf("%v-%d", spec, threads), func(t *testing.T) {
+				board, final := runTurmite(p)
+				p.Turns = 0
+				initial, _ := runTurmite(p)
+				expectedBoard, expectedAnts := referenceTurmite(spec, initial, ants, 300)
+				for i := range expectedAnts {
+					if final[i] != expectedAnts[i] {
+						t.Fatalf("Ant %d is %+v, expected %+v", i, final[i], expectedAnts[i])
+					}
+				}
+				for y := range expectedBoard {
+					for x := range expectedBoard[y] {
+						if board[y][x] != expectedBoard[y][x] {
+							t.Fatalf("Cell (%d, %d) has colour %d, expected %d", x, y, board[y][x], expectedBoard[y][x])
+						}
+					}
+				}
+			})
+		}
+	}
+}
+
+// runTurmite gives the colours of the cells of the PGM output and the ants at the end of a run.
+func runTurmite(p gol.Params) ([][]int, []gol.Ant) {
+	events := make(chan gol.Event)
+	p.OutDir = testOut
+	go gol.Run(p, events, nil)
+	var ants []gol.Ant
+	for event := range events {
+		if e, ok := event.(gol.AntsMoved); ok {
+			ants = e.Ants
+		}
+	}
+	turmite, _ := gol.ParseTurmite(p.Turmite)
+	colours := gol.Rule{States: turmite.Colours()}
+	data, err := ioutil.ReadFile(outImage(fmt.Sprintf("%dx%dx%d", p.ImageWidth, p.ImageHeight, p.Turns)))
+	util.Check(err)
+	image := []byte(strings.Fields(string(data))[4])
+	board := make([][]int, p.ImageHeight)
+	for y := range board {
+		board[y] = make([]int, p.ImageWidth)
+		for x := range board[y] {
+			board[y][x] = colours.State(image[y*p.ImageWidth+x])
+		}
+	}
+	return board, ants
+}
+
+func aliveColours(board [][]int) []util.Cell {
+	var alive []util.Cell
+	for y := range board {
+		for x, colour := range board[y] {
+			if colour == 1 {
+				alive = append(alive, util.Cell{X: x, Y: y})
+			}
+		}
+	}
+	return alive
+}
+
+// referenceTurmite moves the ants one after the other, for turmites written as a string of turns.
+func referenceTurmite(spec string, board [][]int, ants []gol.Ant, turns int) ([][]int, []gol.Ant) {
+	if spec == "{{{1,2,0},{0,8,0}}}" {
+		spec = "RL"
+	}
+	height, width := len(board), len(board[0])
+	ants = append([]gol.Ant(nil), ants...)
+	for turn := 0; turn < turns; turn++ {
+		for i, ant := range ants {
+			colour := board[ant.Y][ant.X]
+			board[ant.Y][ant.X] = (colour + 1) % len(spec)
+			if spec[colour] == 'R' {
+				ant.Direction = (ant.Direction + 1) % 4
+			} else {
+				ant.Direction = (ant.Direction + 3) % 4
+			}
+			//north, east, south, west
+			moves := [4][2]int{{0, -1}, {1, 0}, {0, 1}, {-1, 0}}
+			ant.X = (ant.X + moves[ant.Direction][0] + width) % width
+			ant.Y = (ant.Y + moves[ant.Direction][1] + height) % height
+			ants[i] = ant
+		}
+	}
+	return board, ants
+}
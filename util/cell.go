@@ -1,6 +1,7 @@
 package util
 
 // Cell is used as the return type for the testing framework.
+// Its coordinates are only negative on the unbounded plane of gol.Params.Unbounded.
 type Cell struct {
 	X, Y int
 }
\ No newline at end of file
@@ -60,12 +60,12 @@ func matricesToString(given, expected [][]uint8, width, height int) string {
 }
 
 func getHorizontalBorder(start, middle, end string, width int) string {
-	border := start
-	for i := 0; i < width*2; i++ {
-		border += "─"
-	}
-	border += end
-	return border
+	return HorizontalBorder(start, end, width*2)
+}
+
+// HorizontalBorder draws length box drawing lines between start and end, e.g. "┌───┐".
+func HorizontalBorder(start, end string, length int) string {
+	return start + strings.Repeat("─", length) + end
 }
 
 func squaresToStrings(given, expected [][]uint8, width, height int) []string {
@@ -0,0 +1,109 @@
+package web
+
+// page is the viewer served at /. It draws the board on a canvas from the WebSocket messages
+// and drives the run through the REST endpoints.
+const page = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Game of Life</title>
+<style>
+	body { margin: 0; background: #111; color: #ddd; font: 14px monospace; }
+	#bar { padding: 6px 10px; display: flex; gap: 8px; align-items: center; }
+	#bar span { margin-left: 12px; }
+	canvas { display: block; margin: 0 auto; image-rendering: pixelated; }
+</style>
+</head>
+<body>
+<div id="bar">
+	<button onclick="post('/pause')">Pause</button>
+	<button onclick="post('/resume')">Resume</button>
+	<button onclick="post('/snapshot')">Snapshot</button>
+	<button onclick="post('/quit')">Quit</button>
+	<span id="status">connecting...</span>
+	<span id="message"></span>
+</div>
+<canvas id="board"></canvas>
+<script>
+const canvas = document.getElementById("board");
+const context = canvas.getContext("2d");
+const status = document.getElementById("status");
+const message = document.getElementById("message");
+let width = 0, height = 0, turn = 0, image = null;
+let dirty = false;
+
+function setCell(i, alive) {
+	const v = alive ? 255 : 0;
+	image.data[4*i] = v;
+	image.data[4*i+1] = v;
+	image.data[4*i+2] = v;
+	image.data[4*i+3] = 255;
+}
+
+function fit() {
+	if (width === 0) return;
+	const scale = Math.max(1, Math.floor(Math.min(window.innerWidth / width, (window.innerHeight - 40) / height)));
+	canvas.style.width = (width * scale) + "px";
+	canvas.style.height = (height * scale) + "px";
+}
+window.addEventListener("resize", fit);
+
+function draw() {
+	if (dirty) {
+		context.putImageData(image, 0, 0);
+		dirty = false;
+	}
+	requestAnimationFrame(draw);
+}
+requestAnimationFrame(draw);
+
+function connect() {
+	const ws = new WebSocket("ws://" + location.host + "/ws");
+	ws.binaryType = "arraybuffer";
+	ws.onmessage = function (e) {
+		const view = new DataView(e.data);
+		turn = view.getUint32(1, true);
+		if (view.getUint8(0) === 0) {
+			width = view.getUint32(5, true);
+			height = view.getUint32(9, true);
+			canvas.width = width;
+			canvas.height = height;
+			image = context.createImageData(width, height);
+			const bits = new Uint8Array(e.data, 13);
+			for (let i = 0; i < width * height; i++) {
+				setCell(i, (bits[i >> 3] >> (i & 7)) & 1);
+			}
+			fit();
+		} else if (image !== null) {
+			const count = view.getUint32(5, true);
+			for (let n = 0; n < count; n++) {
+				const i = view.getUint32(9 + 4*n, true);
+				setCell(i, image.data[4*i] === 0);
+			}
+		}
+		dirty = true;
+	};
+	ws.onclose = function () {
+		status.textContent = "disconnected";
+		setTimeout(connect, 1000);
+	};
+}
+connect();
+
+function post(path) {
+	fetch(path, {method: "POST"})
+		.then(r => r.json())
+		.then(body => { if (body.filename) message.textContent = "saved " + body.filename; })
+		.catch(err => { message.textContent = String(err); });
+}
+
+setInterval(function () {
+	fetch("/stats").then(r => r.json()).then(s => {
+		status.textContent = "turn " + s.completed_turns + "  alive " + s.alive + "  " + s.state +
+			"  " + s.turns_per_second.toFixed(1) + " turns/s  " + s.observers + " watching";
+	}).catch(() => {});
+}, 500);
+</script>
+</body>
+</html>
+`
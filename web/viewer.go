@@ -0,0 +1,374 @@
+package web
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// frameInterval limits how often flipped cells are sent to the browsers.
+const frameInterval = 33 * time.Millisecond
+
+// Messages sent over the WebSocket are binary and little endian. The first byte is the message type:
+//
+//	frameKeyframe	turn uint32, width uint32, height uint32, then one bit per cell, row by row
+//	frameDiff    	turn uint32, count uint32, then count uint32 indices (y*width + x) of flipped cells
+const (
+	frameKeyframe byte = 0
+	frameDiff     byte = 1
+)
+
+// client is one browser watching the run.
+type client struct {
+	ws   *websocket
+	send chan []byte
+	// resync is set when a diff had to be dropped because the browser was too slow,
+	// so the next message it gets must be a keyframe.
+	resync bool
+}
+
+// viewer keeps its own copy of the board, built from CellFlipped events, and shares it with every client.
+type viewer struct {
+	mutex          sync.Mutex
+	params         gol.Params
+	keyPresses     chan<- rune
+	board          []bool
+	pending        []bool  // cells flipped an odd number of times since the last diff
+	pendingCells   []int32 // every cell that has been marked pending since the last diff
+	alive          int
+	turn           int
+	state          gol.State
+	wantPaused     bool
+	quitting       bool
+	clients        map[*client]bool
+	imageWaiters   []chan string
+	rateTurn       int
+	rateTime       time.Time
+	turnsPerSecond float64
+}
+
+// Stats is the JSON body returned by GET /stats.
+type Stats struct {
+	CompletedTurns int     `json:"completed_turns"`
+	Alive          int     `json:"alive"`
+	State          string  `json:"state"`
+	TurnsPerSecond float64 `json:"turns_per_second"`
+	Threads        int     `json:"threads"`
+	Width          int     `json:"width"`
+	Height         int     `json:"height"`
+	Observers      int     `json:"observers"`
+}
+
+// Run serves a live view of the Game of Life on addr, e.g. "localhost:8080", until the events channel is closed.
+// Browsers get the page at /, the board over a WebSocket at /ws, and can drive the run with
+// POST /pause, /resume, /snapshot and /quit, which are sent to keyPresses like the keys in sdl.Run.
+// GET /stats gives the current turn, alive cells and state as JSON.
+func Run(p gol.Params, events <-chan gol.Event, keyPresses chan<- rune, addr string) {
+	listener, err := net.Listen("tcp", addr)
+	util.Check(err)
+	Serve(p, events, keyPresses, listener)
+}
+
+// Serve is Run on a listener that is already open, e.g. one on port 0 that the system picked a free port for.
+func Serve(p gol.Params, events <-chan gol.Event, keyPresses chan<- rune, listener net.Listener) {
+	v := &viewer{
+		params:     p,
+		keyPresses: keyPresses,
+		board:      make([]bool, p.ImageWidth*p.ImageHeight),
+		pending:    make([]bool, p.ImageWidth*p.ImageHeight),
+		state:      gol.Executing,
+		clients:    make(map[*client]bool),
+		rateTime:   time.Now(),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", v.servePage)
+	mux.HandleFunc("/ws", v.serveWebSocket)
+	mux.HandleFunc("/stats", v.serveStats)
+	mux.HandleFunc("/pause", v.post(func() { v.setPaused(true) }))
+	mux.HandleFunc("/resume", v.post(func() { v.setPaused(false) }))
+	mux.HandleFunc("/quit", v.post(v.quit))
+	mux.HandleFunc("/snapshot", v.serveSnapshot)
+	server := &http.Server{Handler: mux}
+	go func() {
+		err := server.Serve(listener)
+		if err != http.ErrServerClosed {
+			util.Check(err)
+		}
+	}()
+	fmt.Printf("Watch the Game of Life at http://%v/\n", listener.Addr())
+
+	ticker := time.NewTicker(frameInterval)
+	defer ticker.Stop()
+	lastFrame := time.Now()
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				v.flush()
+				v.closeClients()
+				_ = server.Close()
+				return
+			}
+			v.handle(event)
+			if _, turnComplete := event.(gol.TurnComplete); turnComplete && time.Since(lastFrame) >= frameInterval {
+				v.flush()
+				lastFrame = time.Now()
+			}
+		case <-ticker.C:
+			v.flush()
+			lastFrame = time.Now()
+		}
+	}
+}
+
+// handle updates the board and the stats from one event.
+func (v *viewer) handle(event gol.Event) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	switch e := event.(type) {
+	case gol.CellFlipped:
+		if !onBoard(e.Cell, v.params) {
+			//the unbounded plane is only shown where the board was
+			return
+		}
+		i := int32(e.Cell.Y*v.params.ImageWidth + e.Cell.X)
+		v.board[i] = !v.board[i]
+		if v.board[i] {
+			v.alive++
+		} else {
+			v.alive--
+		}
+		if !v.pending[i] {
+			v.pendingCells = append(v.pendingCells, i)
+		}
+		v.pending[i] = !v.pending[i]
+	case gol.TurnComplete:
+		v.turn = e.CompletedTurns
+		if elapsed := time.Since(v.rateTime); elapsed > time.Second {
+			v.turnsPerSecond = float64(v.turn-v.rateTurn) / elapsed.Seconds()
+			v.rateTurn, v.rateTime = v.turn, time.Now()
+		}
+	case gol.StateChange:
+		v.state = e.NewState
+		if e.NewState == gol.Paused {
+			v.turnsPerSecond = 0
+		}
+		v.rateTurn, v.rateTime = e.CompletedTurns, time.Now()
+	case gol.ThreadsChanged:
+		v.params.Threads = e.Threads
+	case gol.ImageOutputComplete:
+		for _, waiter := range v.imageWaiters {
+			waiter <- e.Filename
+		}
+		v.imageWaiters = nil
+	}
+}
+
+// flush sends the cells flipped since the last diff to every client.
+func (v *viewer) flush() {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	var flipped []int32
+	for _, i := range v.pendingCells {
+		if v.pending[i] {
+			flipped = append(flipped, i)
+			v.pending[i] = false
+		}
+	}
+	v.pendingCells = v.pendingCells[:0]
+	if len(flipped) == 0 {
+		return
+	}
+	diff := make([]byte, 9+4*len(flipped))
+	diff[0] = frameDiff
+	binary.LittleEndian.PutUint32(diff[1:], uint32(v.turn))
+	binary.LittleEndian.PutUint32(diff[5:], uint32(len(flipped)))
+	for n, i := range flipped {
+		binary.LittleEndian.PutUint32(diff[9+4*n:], uint32(i))
+	}
+	for c := range v.clients {
+		message := diff
+		if c.resync {
+			message = v.keyframe()
+		}
+		select {
+		case c.send <- message:
+			c.resync = false
+		default:
+			c.resync = true
+		}
+	}
+}
+
+// keyframe packs the whole board, for browsers that have just connected or fell behind.
+// The caller must hold the mutex.
+func (v *viewer) keyframe() []byte {
+	cells := len(v.board)
+	frame := make([]byte, 13+(cells+7)/8)
+	frame[0] = frameKeyframe
+	binary.LittleEndian.PutUint32(frame[1:], uint32(v.turn))
+	binary.LittleEndian.PutUint32(frame[5:], uint32(v.params.ImageWidth))
+	binary.LittleEndian.PutUint32(frame[9:], uint32(v.params.ImageHeight))
+	for i, alive := range v.board {
+		// cells still pending have not been sent as a diff yet, so the keyframe shows them as the client last saw them
+		if alive != v.pending[i] {
+			frame[13+i/8] |= 1 << uint(i%8)
+		}
+	}
+	return frame
+}
+
+func (v *viewer) closeClients() {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	for c := range v.clients {
+		close(c.send)
+		delete(v.clients, c)
+	}
+}
+
+func (v *viewer) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	ws, err := upgrade(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	c := &client{ws: ws, send: make(chan []byte, 16)}
+	v.mutex.Lock()
+	c.send <- v.keyframe()
+	v.clients[c] = true
+	v.mutex.Unlock()
+
+	// The reading goroutine answers pings and notices when the browser goes away.
+	go func() {
+		for {
+			opcode, payload, err := ws.readFrame()
+			if err != nil || opcode == opClose {
+				break
+			}
+			if opcode == opPing {
+				_ = ws.writeFrame(opPong, payload)
+			}
+		}
+		v.mutex.Lock()
+		if v.clients[c] {
+			delete(v.clients, c)
+			close(c.send)
+		}
+		v.mutex.Unlock()
+	}()
+
+	for message := range c.send {
+		if ws.writeFrame(opBinary, message) != nil {
+			break
+		}
+	}
+	ws.close()
+}
+
+func (v *viewer) stats() Stats {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	return Stats{
+		CompletedTurns: v.turn,
+		Alive:          v.alive,
+		State:          v.state.String(),
+		TurnsPerSecond: v.turnsPerSecond,
+		Threads:        v.params.Threads,
+		Width:          v.params.ImageWidth,
+		Height:         v.params.ImageHeight,
+		Observers:      len(v.clients),
+	}
+}
+
+func (v *viewer) serveStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v.stats())
+}
+
+// post wraps an action so that it only runs for POST requests, and answers with the stats afterwards.
+func (v *viewer) post(action func()) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "use POST", http.StatusMethodNotAllowed)
+			return
+		}
+		action()
+		v.serveStats(w, r)
+	}
+}
+
+// setPaused sends 'p' only if it changes the state, as 'p' toggles between paused and executing.
+// Keys are sent without holding the mutex, which handle needs to take the events the run could be
+// waiting to send before it takes another key.
+func (v *viewer) setPaused(paused bool) {
+	v.mutex.Lock()
+	send := v.wantPaused != paused && !v.quitting
+	if send {
+		v.wantPaused = paused
+	}
+	v.mutex.Unlock()
+	if send {
+		v.keyPresses <- 'p'
+	}
+}
+
+func (v *viewer) quit() {
+	v.mutex.Lock()
+	send := !v.quitting
+	v.quitting = true
+	v.mutex.Unlock()
+	if send {
+		v.keyPresses <- 'q'
+	}
+}
+
+// serveSnapshot asks for a PGM file of the current turn and waits for its name.
+func (v *viewer) serveSnapshot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "use POST", http.StatusMethodNotAllowed)
+		return
+	}
+	waiter := make(chan string, 1)
+	v.mutex.Lock()
+	if v.quitting {
+		v.mutex.Unlock()
+		http.Error(w, "the run is quitting", http.StatusConflict)
+		return
+	}
+	v.imageWaiters = append(v.imageWaiters, waiter)
+	//the params are copied under the mutex, as handle changes their threads
+	p := v.params
+	v.mutex.Unlock()
+	v.keyPresses <- 's'
+
+	select {
+	case filename := <-waiter:
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{"filename": p.ImagePath(filename)})
+	case <-time.After(10 * time.Second):
+		http.Error(w, "no image was output within 10s", http.StatusGatewayTimeout)
+	}
+}
+
+func (v *viewer) servePage(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(page))
+}
+
+// onBoard tells whether a cell is inside the board of p, which the cells of the unbounded plane can leave.
+func onBoard(cell util.Cell, p gol.Params) bool {
+	return cell.X >= 0 && cell.Y >= 0 && cell.X < p.ImageWidth && cell.Y < p.ImageHeight
+}
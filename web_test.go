@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/web"
+)
+
+// webAddr is the address the web viewer of TestWeb listens on.
+var webAddr string
+
+// TestWeb drives a 64x64 run through the REST endpoints of the web viewer
+// and checks that a WebSocket observer gets the same board as /stats reports.
+func TestWeb(t *testing.T) {
+	p := gol.Params{Turns: 100000000, Threads: 4, ImageWidth: 64, ImageHeight: 64}
+	events := make(chan gol.Event, 1000)
+	keyPresses := make(chan rune, 10)
+	p.OutDir = testOut
+	go gol.Run(p, events, keyPresses)
+	//port 0 gets a free port, so that the test does not depend on one being unused
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	webAddr = listener.Addr().String()
+	finished := make(chan bool)
+	go func() {
+		web.Serve(p, events, keyPresses, listener)
+		finished <- true
+	}()
+
+	var stats web.Stats
+	deadline := time.Now().Add(5 * time.Second)
+	for webRequest(http.MethodGet, "/stats", &stats) != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("The web viewer did not start within 5s")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	//pausing twice must only send one 'p'
+	for i := 0; i < 2; i++ {
+		if err := webRequest(http.MethodPost, "/pause", &stats); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deadline = time.Now().Add(5 * time.Second)
+	for stats.State != gol.Paused.String() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected state %v after /pause, got %v", gol.Paused, stats.State)
+		}
+		time.Sleep(50 * time.Millisecond)
+		_ = webRequest(http.MethodGet, "/stats", &stats)
+	}
+	time.Sleep(100 * time.Millisecond)
+	_ = webRequest(http.MethodGet, "/stats", &stats)
+
+	var snapshot struct{ Filename string }
+	if err := webRequest(http.MethodPost, "/snapshot", &snapshot); err != nil {
+		t.Fatal(err)
+	}
+	expected := outImage(fmt.Sprintf("%dx%dx%d", p.ImageWidth, p.ImageHeight, stats.CompletedTurns))
+	if snapshot.Filename != expected {
+		t.Errorf("Expected snapshot %v, got %v", expected, snapshot.Filename)
+	}
+	defer os.Remove(snapshot.Filename)
+
+	//two observers must both get a keyframe of the paused board
+	for observer := 0; observer < 2; observer++ {
+		turn, alive, err := webKeyframe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if turn != stats.CompletedTurns || alive != stats.Alive {
+			t.Errorf("Observer %d got %d alive cells at turn %d, /stats says %d alive cells at turn %d",
+				observer, alive, turn, stats.Alive, stats.CompletedTurns)
+		}
+	}
+
+	if err := webRequest(http.MethodPost, "/resume", &stats); err != nil {
+		t.Fatal(err)
+	}
+	if err := webRequest(http.MethodPost, "/quit", &stats); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatal("The web viewer did not stop within 5s of /quit")
+	}
+	for range events {
+	}
+}
+
+// webRequest sends a request to the web viewer and decodes its JSON answer into v.
+func webRequest(method, path string, v interface{}) error {
+	request, err := http.NewRequest(method, "http://"+webAddr+path, nil)
+	if err != nil {
+		return err
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%v %v: %v", method, path, response.Status)
+	}
+	return json.NewDecoder(response.Body).Decode(v)
+}
+
+// webKeyframe connects to /ws and counts the alive cells in the first message, which is always a keyframe.
+func webKeyframe() (int, int, error) {
+	conn, err := net.Dial("tcp", webAddr)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	fmt.Fprintf(conn, "GET /ws HTTP/1.1\r\nHost: %s\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n", webAddr)
+	reader := bufio.NewReader(conn)
+	response, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	if response.StatusCode != http.StatusSwitchingProtocols {
+		return 0, 0, fmt.Errorf("/ws: %v", response.Status)
+	}
+
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return 0, 0, err
+	}
+	length := int(header[1] & 127)
+	if length == 126 {
+		extended := make([]byte, 2)
+		if _, err := io.ReadFull(reader, extended); err != nil {
+			return 0, 0, err
+		}
+		length = int(binary.BigEndian.Uint16(extended))
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return 0, 0, err
+	}
+	if payload[0] != 0 {
+		return 0, 0, fmt.Errorf("/ws: first message is of type %d, not a keyframe", payload[0])
+	}
+	alive := 0
+	for _, b := range payload[13:] {
+		for ; b != 0; b &= b - 1 {
+			alive++
+		}
+	}
+	return int(binary.LittleEndian.Uint32(payload[1:])), alive, nil
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"strings"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestWireworld loads random circuits from RLE and MCell files, runs them with Wireworld and checks the
+// CellStateChanged events and the grey levels of the PGM output against a simple reference implementation.
+func TestWireworld(t *testing.T) {
+	rulestrings := map[string]string{
+		"Wireworld":     "Wireworld",
+		"WireWorld":     "Wireworld",
+		"HighLife":      "B36/S23",
+		"Brian's Brain": "B2/S/C3",
+		"day and night": "B3678/S34678",
+		"Replicator":    "B1357/S1357",
+	}
+	for rulestring, expected := range rulestrings {
+		rule, err := gol.ParseRule(rulestring)
+		if err != nil || rule.String() != expected {
+			t.Errorf("ParseRule(%q) gave %v, %v, expected %v", rulestring, rule, err, expected)
+		}
+	}
+
+	//an electron running along a wire, from left to right
+	t.Run("wire", func(t *testing.T) {
+		p := wireworldParams(writePattern("x = 6, y = 1, rule = WireWorld\nBA4C!\n", ".rle"), 1, 2)
+		defer os.Remove(p.Generator.Pattern)
+		states := runWireworld(p)
+		expected := []int{3, 3, 2, 1, 3, 3}
+		for x, state := range expected {
+			if states[4][4+x] != state {
+				t.Fatalf("Wire is %v after 2 turns, expected %v", states[4][4:10], expected)
+			}
+		}
+	})
+
+	random := rand.New(rand.NewSource(3))
+	circuit := make([][]int, 40)
+	for y := range circuit {
+		circuit[y] = make([]int, 40)
+		for x := range circuit[y] {
+			//mostly conductors, with some electrons on them
+			switch n := random.Intn(10); {
+			case n < 3:
+			case n < 8:
+				circuit[y][x] = 3
+			default:
+				circuit[y][x] = 1 + random.Intn(2)
+			}
+		}
+	}
+	files := map[string]string{".rle": wireworldRLE(circuit), ".mcl": wireworldMCell(circuit)}
+	for extension, data := range files {
+		for _, threads := range []int{1, 5, 16} {
+			p := wireworldParams(writePattern(data, extension), threads, 40)
+			t.Run(fmt.Sprintf("%v-%d", extension, threads), func(t *testing.T) {
+				defer os.Remove(p.Generator.Pattern)
+				states := runWireworld(p)
+				world := make([][]int, p.ImageHeight)
+				for y := range world {
+					world[y] = make([]int, p.ImageWidth)
+					if y >= 4 && y < 44 {
+						copy(world[y][4:], circuit[y-4])
+					}
+				}
+				expected := referenceWireworld(world, p.Turns)
+				rule, _ := gol.ParseRule(p.Rule)
+				data, err := ioutil.ReadFile(outImage(fmt.Sprintf("%dx%dx%d", p.ImageWidth, p.ImageHeight, p.Turns)))
+				util.Check(err)
+				image := []byte(strings.Fields(string(data))[4])
+				for y := range expected {
+					for x, state := range expected[y] {
+						if states[y][x] != state {
+							t.Fatalf("Cell (%d, %d) is in state %d according to the events, expected %d", x, y, states[y][x], state)
+						}
+						if got := rule.State(image[y*p.ImageWidth+x]); got != state {
+							t.Fatalf("Cell (%d, %d) of the PGM output is in state %d, expected %d", x, y, got, state)
+						}
+					}
+				}
+			})
+		}
+	}
+}
+
+func wireworldParams(pattern string, threads, turns int) gol.Params {
+	return gol.Params{Turns: turns, Threads: threads, ImageWidth: 48, ImageHeight: 48, Rule: "Wireworld",
+		Generator: gol.Generator{Kind: "pattern", Pattern: pattern, X: 4, Y: 4}}
+}
+
+func writePattern(data, extension string) string {
+	f, err := ioutil.TempFile("", "circuit*"+extension)
+	util.Check(err)
+	_, err = f.WriteString(data)
+	util.Check(err)
+	util.Check(f.Close())
+	return f.Name()
+}
+
+// runWireworld gives the states of the cells at the end of a run, from the CellStateChanged events.
+func runWireworld(p gol.Params) [][]int {
+	events := make(chan gol.Event)
+	p.OutDir = testOut
+	go gol.Run(p, events, nil)
+	states := make([][]int, p.ImageHeight)
+	for y := range states {
+		states[y] = make([]int, p.ImageWidth)
+	}
+	for event := range events {
+		if e, ok := event.(gol.CellStateChanged); ok {
+			states[e.Cell.Y][e.Cell.X] = e.State
+		}
+	}
+	return states
+}
+
+// wireworldRLE writes a circuit in RLE, one symbol per cell.
+func wireworldRLE(circuit [][]int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "#C random circuit\nx = %d, y = %d, rule = WireWorld\n", len(circuit[0]), len(circuit))
+	for _, row := range circuit {
+		for _, state := range row {
+			b.WriteString(string(".ABC"[state]))
+		}
+		b.WriteString("$\n")
+	}
+	b.WriteString("!\n")
+	return b.String()
+}
+
+// wireworldMCell writes a circuit in the MCell format, with runs of equal cells.
+func wireworldMCell(circuit [][]int) string {
+	var b strings.Builder
+	b.WriteString("#MCell 4.20\n#GAME Wireworld\n#BOARD 40x40\n")
+	for _, row := range circuit {
+		b.WriteString("#L ")
+		for x := 0; x < len(row); {
+			run := 1
+			for x+run < len(row) && row[x+run] == row[x] {
+				run++
+			}
+			if run > 1 {
+				fmt.Fprint(&b, run)
+			}
+			b.WriteString(string(".ABC"[row[x]]))
+			x += run
+		}
+		b.WriteString("$\n")
+	}
+	return b.String()
+}
+
+// referenceWireworld runs Wireworld the simple way.
+func referenceWireworld(states [][]int, turns int) [][]int {
+	height, width := len(states), len(states[0])
+	for turn := 0; turn < turns; turn++ {
+		next := make([][]int, height)
+		for y := range states {
+			next[y] = make([]int, width)
+			for x, state := range states[y] {
+				heads := 0
+				for j := -1; j <= 1; j++ {
+					for i := -1; i <= 1; i++ {
+						if states[(y+j+height)%height][(x+i+width)%width] == 1 {
+							heads++
+						}
+					}
+				}
+				switch state {
+				case 1:
+					next[y][x] = 2
+				case 2:
+					next[y][x] = 3
+				case 3:
+					next[y][x] = 3
+					if heads == 1 || heads == 2 {
+						next[y][x] = 1
+					}
+				}
+			}
+		}
+		states = next
+	}
+	return states
+}